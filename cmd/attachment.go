@@ -0,0 +1,483 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+// maxAttachmentSize is the largest file that can be attached to a note
+const maxAttachmentSize = 10 << 20
+
+// Attachment is a file attached to a note. Its content is stored once per
+// scope in the attachments directory, under the SHA-256 of the stored bytes;
+// attachments of encrypted notes are encrypted with the note password.
+type Attachment struct {
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash"`
+	Size    int       `json:"size"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type attachmentOutput struct {
+	Name    string    `json:"name"`
+	Size    int       `json:"size"`
+	Hash    string    `json:"hash"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+var (
+	attachAs    string
+	attachForce bool
+	extractOut  string
+)
+
+var noteAttachCmd = &cobra.Command{
+	Use:   "attach [#name] [file]",
+	Short: "Attach a file to a note",
+	Long: `Attach a small file to a note: a VPN config, an SSH public key, a screenshot.
+Attachments of encrypted notes are encrypted with the note password.
+
+Example: al note attach #vpn client.ovpn
+         al note attach #access id_ed25519.pub --as deploy.pub`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteAttach,
+}
+
+var noteAttachmentsCmd = &cobra.Command{
+	Use:               "attachments [#name]",
+	Short:             "List the attachments of a note",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteAttachments,
+}
+
+var noteExtractCmd = &cobra.Command{
+	Use:   "extract [#name] [file]",
+	Short: "Write an attachment of a note to a file",
+	Long: `Write an attachment of a note to a file of the same name in the current
+directory, or to the path given with --out ('-' for the standard output).
+
+Example: al note extract #vpn client.ovpn
+         al note extract #vpn client.ovpn --out /etc/openvpn/client.conf`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeNoteAttachments,
+	RunE:              runNoteExtract,
+}
+
+var noteDetachCmd = &cobra.Command{
+	Use:               "detach [#name] [file]",
+	Short:             "Remove an attachment from a note",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeNoteAttachments,
+	RunE:              runNoteDetach,
+}
+
+func init() {
+	noteAttachCmd.Flags().StringVar(&attachAs, "as", "", "Name of the attachment (defaults to the file name)")
+	noteAttachCmd.Flags().BoolVarP(&attachForce, "force", "f", false, "Replace an attachment of the same name")
+	noteExtractCmd.Flags().StringVar(&extractOut, "out", "", "Destination path, '-' for the standard output")
+
+	noteCmd.AddCommand(noteAttachCmd)
+	noteCmd.AddCommand(noteAttachmentsCmd)
+	noteCmd.AddCommand(noteExtractCmd)
+	noteCmd.AddCommand(noteDetachCmd)
+}
+
+func getAttachmentsDir(projectPath string) string {
+	return filepath.Join(storage.GetDataDir(projectPath), "attachments")
+}
+
+func getAttachmentPath(projectPath, hash string) string {
+	return filepath.Join(getAttachmentsDir(projectPath), hash)
+}
+
+// findAttachment returns the index of the attachment named name
+func (n *Note) findAttachment(name string) int {
+	for i, a := range n.Attachments {
+		if strings.EqualFold(a.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// attachmentNames returns the names of the attachments of a note
+func (n *Note) attachmentNames() []string {
+	var names []string
+	for _, a := range n.Attachments {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// storeAttachment writes data to the attachments of a scope, encrypted when
+// password is set, and returns the hash it is stored under
+func storeAttachment(projectPath string, data []byte, password string) (string, error) {
+	stored := data
+	if password != "" {
+		encrypted, err := utils.Encrypt(base64.StdEncoding.EncodeToString(data), password)
+		if err != nil {
+			return "", err
+		}
+		stored = []byte(encrypted)
+	}
+
+	sum := sha256.Sum256(stored)
+	hash := hex.EncodeToString(sum[:])
+
+	if err := ensureDataDir(getAttachmentsDir(projectPath)); err != nil {
+		return "", err
+	}
+	path := getAttachmentPath(projectPath, hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	return hash, os.WriteFile(path, stored, 0600)
+}
+
+// readAttachment returns the content of an attachment, checking its hash
+func readAttachment(projectPath string, attachment Attachment, password string) ([]byte, error) {
+	stored, err := os.ReadFile(getAttachmentPath(projectPath, attachment.Hash))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("the content of attachment '%s' is missing", attachment.Name)
+		}
+		return nil, err
+	}
+
+	sum := sha256.Sum256(stored)
+	if hex.EncodeToString(sum[:]) != attachment.Hash {
+		return nil, fmt.Errorf("the content of attachment '%s' is corrupted", attachment.Name)
+	}
+
+	if password == "" {
+		return stored, nil
+	}
+	decrypted, err := utils.Decrypt(string(stored), password)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(decrypted)
+}
+
+// copyAttachments copies the stored attachments of a note to another scope
+func copyAttachments(sourcePath, destPath string, attachments []Attachment) error {
+	if sourcePath == destPath || len(attachments) == 0 {
+		return nil
+	}
+	if err := ensureDataDir(getAttachmentsDir(destPath)); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		dest := getAttachmentPath(destPath, a.Hash)
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		data, err := os.ReadFile(getAttachmentPath(sourcePath, a.Hash))
+		if err != nil {
+			return fmt.Errorf("cannot copy attachment '%s': %w", a.Name, err)
+		}
+		if err := os.WriteFile(dest, data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeUnusedAttachments deletes the stored attachments that no note of the
+// scope refers to anymore
+func removeUnusedAttachments(projectPath string, attachments []Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	notes, err := listNotes(projectPath)
+	if err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	for _, note := range notes {
+		for _, a := range note.Attachments {
+			used[a.Hash] = true
+		}
+	}
+
+	for _, a := range attachments {
+		if used[a.Hash] {
+			continue
+		}
+		if err := os.Remove(getAttachmentPath(projectPath, a.Hash)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// notePassword asks for the password of an encrypted note and checks it
+// against the note content. It returns an empty password for other notes.
+func notePassword(note *Note) (string, error) {
+	if !note.Encrypted {
+		return "", nil
+	}
+
+	password, err := utils.ReadPassword("Enter decryption password: ")
+	if err != nil {
+		return "", err
+	}
+	if _, err := utils.Decrypt(note.Content, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// loadNoteArg loads the note named by a command argument, suggesting
+// similar names when it does not exist
+func loadNoteArg(projectPath, arg string) (*Note, error) {
+	noteName := strings.TrimPrefix(arg, "#")
+
+	note, err := loadNote(projectPath, noteName)
+	if err != nil {
+		return nil, reportNoteNotFound(projectPath, noteName, err)
+	}
+	return note, nil
+}
+
+// reportAttachmentNotFound suggests the attachments of the note with a close name
+func reportAttachmentNotFound(note *Note, name string) error {
+	return reportNotFound("attachment", name, utils.FindSimilarStrings(name, note.attachmentNames(), 3))
+}
+
+// completeNoteAttachments completes the note, then the names of its attachments
+func completeNoteAttachments(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeNoteNames(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	note, err := loadNote(projectPath, strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completionCandidates(note.attachmentNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runNoteAttach(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	name := attachAs
+	if name == "" {
+		name = filepath.Base(args[1])
+	}
+	if err := validateName("attachment", name); err != nil {
+		return err
+	}
+
+	index := note.findAttachment(name)
+	if index >= 0 && !attachForce {
+		return fmt.Errorf("note '%s' already has an attachment named '%s' (use --force to replace it)", note.Name, note.Attachments[index].Name)
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return fmt.Errorf("'%s' is a directory", args[1])
+	}
+
+	data, err := readLimitedTo(f, args[1], maxAttachmentSize)
+	if err != nil {
+		return err
+	}
+
+	password, err := notePassword(note)
+	if err != nil {
+		return err
+	}
+
+	hash, err := storeAttachment(projectPath, data, password)
+	if err != nil {
+		return err
+	}
+
+	attachment := Attachment{Name: name, Hash: hash, Size: len(data), AddedAt: time.Now()}
+	var replaced []Attachment
+	if index >= 0 {
+		replaced = append(replaced, note.Attachments[index])
+		note.Attachments[index] = attachment
+	} else {
+		note.Attachments = append(note.Attachments, attachment)
+	}
+	note.UpdatedAt = time.Now()
+
+	if err := saveNote(projectPath, note); err != nil {
+		return err
+	}
+	if err := removeUnusedAttachments(projectPath, replaced); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ '%s' attached to note '%s' (%s)\n", name, note.Name, formatSize(len(data)))
+	return nil
+}
+
+func runNoteAttachments(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		out := make([]attachmentOutput, 0, len(note.Attachments))
+		for _, a := range note.Attachments {
+			out = append(out, attachmentOutput{Name: a.Name, Size: a.Size, Hash: a.Hash, AddedAt: a.AddedAt})
+		}
+		return printOutput(out)
+	}
+
+	if len(note.Attachments) == 0 {
+		fmt.Printf("Note '%s' has no attachments.\n", note.Name)
+		return nil
+	}
+
+	dateFormat := effectiveConfig(projectPath).DateFormat
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tSize\tDate")
+	fmt.Fprintln(w, "----\t----\t----")
+	for _, a := range note.Attachments {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", a.Name, formatSize(a.Size), a.AddedAt.Format(dateFormat))
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runNoteExtract(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	index := note.findAttachment(args[1])
+	if index < 0 {
+		return reportAttachmentNotFound(note, args[1])
+	}
+	attachment := note.Attachments[index]
+
+	out := extractOut
+	if out == "" {
+		out = filepath.Base(attachment.Name)
+	}
+	if out != stdinArg {
+		if _, err := os.Stat(out); err == nil {
+			return fmt.Errorf("'%s' already exists (choose another path with --out)", out)
+		}
+	}
+
+	password, err := notePassword(note)
+	if err != nil {
+		return err
+	}
+	data, err := readAttachment(projectPath, attachment, password)
+	if err != nil {
+		return err
+	}
+
+	if out == stdinArg {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(out, data, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ '%s' extracted to %s\n", attachment.Name, out)
+	return nil
+}
+
+func runNoteDetach(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	index := note.findAttachment(args[1])
+	if index < 0 {
+		return reportAttachmentNotFound(note, args[1])
+	}
+	attachment := note.Attachments[index]
+
+	if !utils.AskConfirmation(fmt.Sprintf("Are you sure you want to remove '%s' from note '%s'?", attachment.Name, note.Name)) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	note.Attachments = append(note.Attachments[:index], note.Attachments[index+1:]...)
+	note.UpdatedAt = time.Now()
+
+	if err := saveNote(projectPath, note); err != nil {
+		return err
+	}
+	if err := removeUnusedAttachments(projectPath, []Attachment{attachment}); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ '%s' removed from note '%s'\n", attachment.Name, note.Name)
+	return nil
+}
+
+// formatSize returns a size in bytes in a readable unit
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
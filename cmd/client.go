@@ -0,0 +1,458 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+type contactOutput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Role  string `json:"role"`
+}
+
+type clientOutput struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Projects    []string  `json:"projects"`
+	Notes       int       `json:"notes"`
+	Links       int       `json:"links"`
+	Contacts    int       `json:"contacts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// clientDetailsOutput is a client shown by al client show
+type clientDetailsOutput struct {
+	clientOutput
+	ContactList []contactOutput `json:"contact_list"`
+	NoteNames   []string        `json:"note_names"`
+	LinkNames   []string        `json:"link_names"`
+}
+
+var (
+	clientDescription string
+	contactEmail      string
+	contactPhone      string
+	contactRole       string
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client [action]",
+	Short: "Manage clients and their shared notes and links",
+	Long: `Manage the clients grouping several projects. A client has contacts and its
+own notes and links, shared by its projects: use --target client:<name> with
+al note and al link. Projects are attached to a client with
+al project set client <name>. Actions: list, show, add, contact
+
+Example: al client add acme -d "ACME Corp"
+         al project set client acme
+         al note add #vpn -t client:acme -c
+         al client show acme`,
+	Args: cobra.NoArgs,
+	RunE: runClientList,
+}
+
+var clientListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List clients",
+	Args:  cobra.NoArgs,
+	RunE:  runClientList,
+}
+
+var clientShowCmd = &cobra.Command{
+	Use:               "show [name]",
+	Short:             "Show a client, its contacts, projects, notes and links",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeClientNames,
+	RunE:              runClientShow,
+}
+
+var clientAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Register a client",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runClientAdd,
+}
+
+var clientContactCmd = &cobra.Command{
+	Use:   "contact [action]",
+	Short: "Manage the contacts of a client",
+	Long: `Manage the contacts of a client. Actions: add, remove
+
+Example: al client contact add acme "Jane Doe" --email jane@acme.io --role CTO
+         al client contact remove acme "Jane Doe"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var clientContactAddCmd = &cobra.Command{
+	Use:               "add [client] [name]",
+	Short:             "Add or update a contact",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeClientNames,
+	RunE:              runClientContactAdd,
+}
+
+var clientContactRemoveCmd = &cobra.Command{
+	Use:               "remove [client] [name]",
+	Short:             "Remove a contact",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeClientContacts,
+	RunE:              runClientContactRemove,
+}
+
+func init() {
+	clientAddCmd.Flags().StringVarP(&clientDescription, "description", "d", "", "Description of the client")
+	clientContactAddCmd.Flags().StringVar(&contactEmail, "email", "", "Email address")
+	clientContactAddCmd.Flags().StringVar(&contactPhone, "phone", "", "Phone number")
+	clientContactAddCmd.Flags().StringVar(&contactRole, "role", "", "Role at the client")
+
+	clientContactCmd.AddCommand(clientContactAddCmd)
+	clientContactCmd.AddCommand(clientContactRemoveCmd)
+
+	clientCmd.AddCommand(clientListCmd)
+	clientCmd.AddCommand(clientShowCmd)
+	clientCmd.AddCommand(clientAddCmd)
+	clientCmd.AddCommand(clientContactCmd)
+}
+
+// clientProjects returns the names of the projects attached to a client
+func clientProjects(projects map[string]storage.Project, clientName string) []string {
+	names := []string{}
+	for name, project := range projects {
+		if strings.EqualFold(project.Client, clientName) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findClientArg finds a registered client, suggesting similar names when it
+// does not exist
+func findClientArg(name string) (string, storage.Client, error) {
+	clientName, client, err := storage.FindClient(name)
+	if err != nil {
+		clients, _ := storage.LoadClients()
+		var names []string
+		for n := range clients {
+			names = append(names, n)
+		}
+		return "", client, reportNotFound("client", name, utils.FindSimilarStrings(name, names, 3))
+	}
+	return clientName, client, nil
+}
+
+func newClientOutput(name string, client storage.Client, projects map[string]storage.Project) (clientOutput, []Note, []Link, error) {
+	dir := storage.GetClientDir(name)
+	notes, err := listNotes(dir)
+	if err != nil {
+		return clientOutput{}, nil, nil, err
+	}
+	links, err := listLinks(dir)
+	if err != nil {
+		return clientOutput{}, nil, nil, err
+	}
+
+	return clientOutput{
+		Name:        name,
+		Description: client.Description,
+		Projects:    clientProjects(projects, name),
+		Notes:       len(notes),
+		Links:       len(links),
+		Contacts:    len(client.Contacts),
+		CreatedAt:   client.CreatedAt,
+	}, notes, links, nil
+}
+
+// completeClientNames completes the first argument with the client names
+func completeClientNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name := range clients {
+		names = append(names, name)
+	}
+	return completionCandidates(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeClientContacts completes the client, then the names of its contacts
+func completeClientContacts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeClientNames(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	_, client, err := storage.FindClient(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, contact := range client.Contacts {
+		names = append(names, contact.Name)
+	}
+	return completionCandidates(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runClientList(cmd *cobra.Command, args []string) error {
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return err
+	}
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]clientOutput, 0, len(names))
+	for _, name := range names {
+		c, _, _, err := newClientOutput(name, clients[name], projects)
+		if err != nil {
+			return err
+		}
+		out = append(out, c)
+	}
+
+	if isStructuredOutput() {
+		return printOutput(out)
+	}
+
+	if len(out) == 0 {
+		fmt.Println("No clients found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tProjects\tNotes\tLinks\tContacts\tDescription")
+	fmt.Fprintln(w, "----\t--------\t-----\t-----\t--------\t-----------")
+	for _, c := range out {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", c.Name, strings.Join(c.Projects, ", "), c.Notes, c.Links, c.Contacts, c.Description)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runClientShow(cmd *cobra.Command, args []string) error {
+	name, client, err := findClientArg(args[0])
+	if name == "" {
+		return err
+	}
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	summary, notes, links, err := newClientOutput(name, client, projects)
+	if err != nil {
+		return err
+	}
+
+	out := clientDetailsOutput{
+		clientOutput: summary,
+		ContactList:  []contactOutput{},
+		NoteNames:    []string{},
+		LinkNames:    []string{},
+	}
+	for _, c := range client.Contacts {
+		out.ContactList = append(out.ContactList, contactOutput{Name: c.Name, Email: c.Email, Phone: c.Phone, Role: c.Role})
+	}
+	for _, note := range notes {
+		out.NoteNames = append(out.NoteNames, note.Name)
+	}
+	for _, link := range links {
+		out.LinkNames = append(out.LinkNames, link.Name)
+	}
+
+	if isStructuredOutput() {
+		return printOutput(out)
+	}
+
+	renderer := markdownRenderer{color: useColor()}
+	heading := func(title string) {
+		fmt.Println()
+		fmt.Println(renderer.style(title, ansiBold))
+	}
+
+	fmt.Println(renderer.style(name, ansiBold, ansiMagenta))
+	if client.Description != "" {
+		fmt.Println(client.Description)
+	}
+
+	if len(out.ContactList) > 0 {
+		heading("Contacts")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, c := range out.ContactList {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Name, c.Role, c.Email, c.Phone)
+		}
+		w.Flush()
+	}
+
+	if len(out.Projects) > 0 {
+		heading("Projects")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, p := range out.Projects {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", p, projects[p].ProjectStatus(), projects[p].Path)
+		}
+		w.Flush()
+	}
+
+	if len(out.NoteNames) > 0 {
+		heading("Notes")
+		fmt.Println("  " + strings.Join(out.NoteNames, ", "))
+	}
+	if len(out.LinkNames) > 0 {
+		heading("Links")
+		fmt.Println("  " + strings.Join(out.LinkNames, ", "))
+	}
+
+	fmt.Println()
+	fmt.Printf("Use -t %s%s with al note and al link for the client notes and links.\n", clientScopePrefix, name)
+	return nil
+}
+
+func runClientAdd(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(args[0])
+	if err := validateName("client", name); err != nil {
+		return err
+	}
+	if strings.Contains(name, ":") {
+		return fmt.Errorf("invalid client name '%s': ':' is not allowed", name)
+	}
+
+	if err := storage.EnsureGlobalDir(); err != nil {
+		return fmt.Errorf("failed to initialize global directory: %w", err)
+	}
+
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return err
+	}
+	for existing := range clients {
+		if strings.EqualFold(existing, name) || storage.GetClientDir(existing) == storage.GetClientDir(name) {
+			return fmt.Errorf("client '%s' already exists", existing)
+		}
+	}
+
+	if err := ensureDataDir(storage.GetClientDir(name)); err != nil {
+		return err
+	}
+
+	clients[name] = storage.Client{Description: clientDescription, CreatedAt: time.Now()}
+	if err := storage.SaveClients(clients); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Client '%s' created\n", name)
+	return nil
+}
+
+func runClientContactAdd(cmd *cobra.Command, args []string) error {
+	name, client, err := findClientArg(args[0])
+	if name == "" {
+		return err
+	}
+
+	contactName := strings.TrimSpace(args[1])
+	if contactName == "" {
+		return fmt.Errorf("contact name cannot be empty")
+	}
+
+	contact := storage.Contact{Name: contactName, Email: contactEmail, Phone: contactPhone, Role: contactRole}
+	updated := false
+	for i, c := range client.Contacts {
+		if strings.EqualFold(c.Name, contactName) {
+			// Only the given fields are changed
+			contact.Name = c.Name
+			if !cmd.Flags().Changed("email") {
+				contact.Email = c.Email
+			}
+			if !cmd.Flags().Changed("phone") {
+				contact.Phone = c.Phone
+			}
+			if !cmd.Flags().Changed("role") {
+				contact.Role = c.Role
+			}
+			client.Contacts[i] = contact
+			contactName, updated = c.Name, true
+		}
+	}
+	if !updated {
+		client.Contacts = append(client.Contacts, contact)
+	}
+
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return err
+	}
+	clients[name] = client
+	if err := storage.SaveClients(clients); err != nil {
+		return err
+	}
+
+	if updated {
+		fmt.Printf("✓ Contact '%s' of client '%s' updated\n", contactName, name)
+	} else {
+		fmt.Printf("✓ Contact '%s' added to client '%s'\n", contactName, name)
+	}
+	return nil
+}
+
+func runClientContactRemove(cmd *cobra.Command, args []string) error {
+	name, client, err := findClientArg(args[0])
+	if name == "" {
+		return err
+	}
+
+	index := -1
+	var names []string
+	for i, c := range client.Contacts {
+		names = append(names, c.Name)
+		if strings.EqualFold(c.Name, args[1]) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return reportNotFound("contact", args[1], utils.FindSimilarStrings(args[1], names, 3))
+	}
+
+	removed := client.Contacts[index].Name
+	client.Contacts = append(client.Contacts[:index], client.Contacts[index+1:]...)
+
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return err
+	}
+	clients[name] = client
+	if err := storage.SaveClients(clients); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Contact '%s' removed from client '%s'\n", removed, name)
+	return nil
+}
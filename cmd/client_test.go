@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alex/al/storage"
+)
+
+// setupTestClients registers the project web of client Acme and returns its path
+func setupTestClients(t *testing.T) string {
+	t.Helper()
+	setupTestHome(t)
+	project := t.TempDir()
+	if err := storage.SaveProjects(map[string]storage.Project{"web": {Path: project, Shortcuts: []string{"web", "w"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveClients(map[string]storage.Client{"Acme Corp": {}, "Globex": {}}); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestResolveTarget(t *testing.T) {
+	project := setupTestClients(t)
+
+	tests := []struct {
+		target  string
+		want    string
+		wantErr string
+	}{
+		{target: "web", want: project},
+		{target: "W", want: project},
+		{target: "client:Acme Corp", want: storage.GetClientDir("Acme Corp")},
+		{target: "client:acme corp", want: storage.GetClientDir("Acme Corp")},
+		{target: "client:GLOBEX", want: storage.GetClientDir("Globex")},
+		{target: "client:initech", wantErr: "client 'initech' not found"},
+		{target: "api", wantErr: "project 'api' not found"},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveTarget(tt.target)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("resolveTarget(%q) = %q, %v, want %q", tt.target, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveTarget(%q) = %q, %v, want %q", tt.target, got, err, tt.want)
+		}
+	}
+
+	if filepath.Base(storage.GetClientDir("Acme Corp")) != "acme-corp" {
+		t.Errorf("client directory %s not slugified", storage.GetClientDir("Acme Corp"))
+	}
+	if _, err := resolveProjectPath("client:acme corp"); err == nil || !strings.Contains(err.Error(), "is a client, not a project") {
+		t.Errorf("resolveProjectPath accepted a client: %v", err)
+	}
+}
+
+func TestIsClientDir(t *testing.T) {
+	project := setupTestClients(t)
+	dir := storage.GetClientDir("Acme Corp")
+
+	tests := map[string]bool{
+		dir:                       true,
+		dir + "/":                 true,
+		filepath.Dir(dir):         false,
+		filepath.Join(dir, "sub"): false,
+		project:                   false,
+		"":                        false,
+		storage.GetClientDir("x"): true,
+	}
+	for path, want := range tests {
+		if got := storage.IsClientDir(path); got != want {
+			t.Errorf("IsClientDir(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if got := clientNameOfDir(dir); got != "Acme Corp" {
+		t.Errorf("clientNameOfDir = %q, want the registered name", got)
+	}
+}
+
+func TestProjectSetClient(t *testing.T) {
+	project := setupTestClients(t)
+	t.Cleanup(func() { projectTarget = "" })
+	projectTarget = "web"
+
+	client := func() string {
+		t.Helper()
+		_, p, err := storage.FindProjectByPath(project)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Client
+	}
+
+	captureStdout(t, func() {
+		if err := runProjectSet(projectSetCmd, []string{"client", "acme", "corp"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := client(); got != "Acme Corp" {
+		t.Errorf("client = %q, want the registered spelling", got)
+	}
+
+	if err := runProjectSet(projectSetCmd, []string{"client", "initech"}); err == nil || !strings.Contains(err.Error(), "client 'initech' not found") {
+		t.Errorf("unregistered client accepted: %v", err)
+	}
+	if got := client(); got != "Acme Corp" {
+		t.Errorf("client = %q after a refused change", got)
+	}
+
+	captureStdout(t, func() {
+		if err := runProjectSet(projectSetCmd, []string{"client", ""}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := client(); got != "" {
+		t.Errorf("client = %q, want it cleared", got)
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+// Binaries that are aliases of al subcommands (see main.go)
+var aliasBinaries = []string{"algo", "alinit", "alnote", "allink"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the shell completion script for al and its aliases (algo, alnote, allink, alinit).
+
+Bash:  source <(al completion bash)
+Zsh:   source <(al completion zsh)
+Fish:  al completion fish | source
+
+To load completions for every session, write the output to your shell
+completion directory or add the line above to your shell configuration.`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := os.Stdout
+	aliases := strings.Join(aliasBinaries, " ")
+
+	switch args[0] {
+	case "bash":
+		if err := rootCmd.GenBashCompletionV2(out, true); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `
+if [[ $(type -t compopt) = "builtin" ]]; then
+    complete -o default -F __start_al %[1]s
+else
+    complete -o default -o nospace -F __start_al %[1]s
+fi
+`, aliases)
+	case "zsh":
+		if err := rootCmd.GenZshCompletion(out); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\ncompdef _al %s\n", aliases)
+	case "fish":
+		if err := rootCmd.GenFishCompletion(out, true); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+		for _, alias := range aliasBinaries {
+			fmt.Fprintf(out, `complete -c %[1]s -e
+complete -c %[1]s -n '__al_clear_perform_completion_once_result'
+complete -c %[1]s -n 'not __al_requires_order_preservation && __al_prepare_completions' -f -a '$__al_comp_results'
+complete -k -c %[1]s -n '__al_requires_order_preservation && __al_prepare_completions' -f -a '$__al_comp_results'
+`, alias)
+		}
+	default:
+		return fmt.Errorf("unknown shell '%s' (expected bash, zsh or fish)", args[0])
+	}
+
+	return nil
+}
+
+// completionCandidates filters candidates ("value\tdescription") on the
+// word being completed, keeping the '#' prefix used for note and link names
+func completionCandidates(candidates []string, toComplete string) []string {
+	prefix := ""
+	if strings.HasPrefix(toComplete, "#") {
+		prefix = "#"
+		toComplete = strings.TrimPrefix(toComplete, "#")
+	}
+
+	seen := make(map[string]bool)
+	var completions []string
+	for _, c := range candidates {
+		value := strings.SplitN(c, "\t", 2)[0]
+		if seen[value] || !strings.HasPrefix(strings.ToLower(value), strings.ToLower(toComplete)) {
+			continue
+		}
+		seen[value] = true
+		completions = append(completions, prefix+c)
+	}
+
+	sort.Strings(completions)
+	return completions
+}
+
+// completeProjects completes project names and shortcuts
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for name, project := range projects {
+		if project.Archived() {
+			continue
+		}
+		candidates = append(candidates, name+"\t"+project.Path)
+		for _, s := range project.Shortcuts {
+			if s != name {
+				candidates = append(candidates, s+"\t"+name)
+			}
+		}
+	}
+
+	return completionCandidates(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTargets completes the --target values of notes and links: the
+// projects and the client:<name> scopes
+func completeTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates, directive := completeProjects(cmd, args, toComplete)
+
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return candidates, directive
+	}
+	var names []string
+	for name := range clients {
+		names = append(names, clientScopePrefix+name+"\tClient notes and links")
+	}
+	return append(candidates, completionCandidates(names, toComplete)...), directive
+}
+
+// completeProjectArg completes the single project argument of al go
+func completeProjectArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProjects(cmd, args, toComplete)
+}
+
+// completeNoteNames completes note names of the targeted project
+func completeNoteNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	notes, err := listNotes(projectPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for _, note := range notes {
+		candidates = append(candidates, note.Name)
+	}
+
+	return completionCandidates(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLinkNames completes link names and keywords of the targeted project
+func completeLinkNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	links, err := listLinks(projectPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for _, link := range links {
+		description := link.URL
+		if link.Title != "" {
+			description = link.Title
+		}
+		candidates = append(candidates, link.Name+"\t"+description)
+		for _, keyword := range link.Keywords {
+			candidates = append(candidates, keyword+"\t"+link.Name)
+		}
+	}
+
+	return completionCandidates(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEnvNames completes variable names of the targeted project
+func completeEnvNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for name := range vars {
+		candidates = append(candidates, name)
+	}
+
+	return completionCandidates(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	candidates := []string{"deploy\tRunbook", "Docs", "backup", "deploy\tduplicate", "dns"}
+
+	tests := []struct {
+		toComplete string
+		want       []string
+	}{
+		{toComplete: "", want: []string{"Docs", "backup", "deploy\tRunbook", "dns"}},
+		{toComplete: "d", want: []string{"Docs", "deploy\tRunbook", "dns"}},
+		{toComplete: "DE", want: []string{"deploy\tRunbook"}},
+		{toComplete: "#d", want: []string{"#Docs", "#deploy\tRunbook", "#dns"}},
+		{toComplete: "#", want: []string{"#Docs", "#backup", "#deploy\tRunbook", "#dns"}},
+		{toComplete: "x"},
+	}
+
+	for _, tt := range tests {
+		if got := completionCandidates(candidates, tt.toComplete); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completionCandidates(%q) = %q, want %q", tt.toComplete, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteTargets(t *testing.T) {
+	setupTestHome(t)
+	projects := map[string]storage.Project{
+		"web":  {Path: "/srv/web", Shortcuts: []string{"web", "w"}},
+		"old":  {Path: "/srv/old", Shortcuts: []string{"old"}, Status: storage.ProjectArchived},
+		"wiki": {Path: "/srv/wiki"},
+	}
+	if err := storage.SaveProjects(projects); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveClients(map[string]storage.Client{"acme": {}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, directive := completeProjects(nil, nil, "w")
+	want := []string{"w\tweb", "web\t/srv/web", "wiki\t/srv/wiki"}
+	if !reflect.DeepEqual(got, want) || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("completeProjects = %q, want %q", got, want)
+	}
+	if got, _ := completeProjects(nil, nil, "o"); len(got) != 0 {
+		t.Errorf("archived project completed: %q", got)
+	}
+
+	got, _ = completeTargets(nil, nil, "")
+	if !contains(got, "client:acme\tClient notes and links") || !contains(got, "web\t/srv/web") {
+		t.Errorf("completeTargets = %q", got)
+	}
+	if got, _ := completeTargets(nil, nil, "client:a"); !reflect.DeepEqual(got, []string{"client:acme\tClient notes and links"}) {
+		t.Errorf("completeTargets(client:a) = %q", got)
+	}
+	if got, _ := completeProjectArg(nil, []string{"web"}, ""); got != nil {
+		t.Errorf("completeProjectArg completed a second argument: %q", got)
+	}
+}
+
+func TestCompleteNoteAndLinkNames(t *testing.T) {
+	setupTestHome(t)
+	t.Cleanup(func() { noteGlobal, linkGlobal = false, false })
+	noteGlobal, linkGlobal = true, true
+
+	for _, name := range []string{"deploy", "Docs"} {
+		if err := saveNote("", &Note{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveLink("", &Link{Name: "grafana", URL: "https://grafana.example.com", Title: "Grafana", Keywords: []string{"dashboards"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLink("", &Link{Name: "docs", URL: "https://docs.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := completeNoteNames(nil, nil, "#d"); !reflect.DeepEqual(got, []string{"#Docs", "#deploy"}) {
+		t.Errorf("completeNoteNames = %q", got)
+	}
+	if got, _ := completeNoteNames(nil, []string{"#deploy"}, ""); got != nil {
+		t.Errorf("completeNoteNames completed a second argument: %q", got)
+	}
+
+	got, _ := completeLinkNames(nil, nil, "")
+	want := []string{"dashboards\tgrafana", "docs\thttps://docs.example.com", "grafana\tGrafana"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("completeLinkNames = %q, want %q", got, want)
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "bash", want: []string{"__start_al algo alinit alnote allink"}},
+		{shell: "zsh", want: []string{"compdef _al algo alinit alnote allink"}},
+		{shell: "fish", want: []string{"complete -c algo -e", "complete -k -c allink -n"}},
+	}
+
+	for _, tt := range tests {
+		out := captureStdout(t, func() {
+			if err := runCompletion(completionCmd, []string{tt.shell}); err != nil {
+				t.Fatal(err)
+			}
+		})
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s completion does not contain %q", tt.shell, want)
+			}
+		}
+
+		if tt.shell == "bash" {
+			if bash, err := exec.LookPath("bash"); err == nil {
+				script := filepath.Join(t.TempDir(), "al.bash")
+				if err := os.WriteFile(script, []byte(out), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if out, err := exec.Command(bash, "-n", script).CombinedOutput(); err != nil {
+					t.Errorf("invalid bash script: %v\n%s", err, out)
+				}
+			}
+		}
+	}
+
+	if err := runCompletion(completionCmd, []string{"powershell"}); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+type configOutput struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+	Path   string `json:"path"`
+}
+
+var (
+	configTarget     string
+	configLocal      bool
+	configShowOrigin bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config [action]",
+	Short: "Manage al settings",
+	Long: `Manage al settings. Actions: list, get, set, unset, edit
+
+Settings are read from ~/.al_global/config, then overridden by the
+.al_local/config file of the current (or targeted) project.
+Use --local to change the project file instead of the global one.
+The editor and browser commands and the output format are only read from the
+global file, so a cloned project cannot make al run its own programs or change
+the output of scripts.`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get [key]",
+	Short:             "Get a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set [key] [value]",
+	Short:             "Set a setting",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset [key]",
+	Short:             "Remove a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigUnset,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEdit,
+}
+
+func init() {
+	// Add flags
+	configCmd.PersistentFlags().StringVarP(&configTarget, "target", "t", "", "Target project")
+	configCmd.RegisterFlagCompletionFunc("target", completeProjects)
+	configCmd.PersistentFlags().BoolVarP(&configLocal, "local", "l", false, "Use the project config file")
+	configListCmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "Show where each value comes from")
+
+	// Add subcommands
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEditCmd)
+}
+
+// getConfigProjectPath returns the project whose config file is layered over
+// the global one, or an empty path outside of any project
+func getConfigProjectPath() (string, error) {
+	if configTarget != "" {
+		_, project, err := storage.FindProjectByShortcut(configTarget)
+		if err != nil {
+			return "", fmt.Errorf("project '%s' not found", configTarget)
+		}
+		return project.Path, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	_, project, err := storage.FindProjectByPath(cwd)
+	if err != nil {
+		return "", nil
+	}
+	return project.Path, nil
+}
+
+// getConfigLayerPath returns the config file changed by set, unset and edit
+func getConfigLayerPath() (string, error) {
+	if !configLocal {
+		if err := storage.EnsureGlobalDir(); err != nil {
+			return "", err
+		}
+		return storage.GetGlobalConfigPath()
+	}
+
+	projectPath, err := getConfigProjectPath()
+	if err != nil {
+		return "", err
+	}
+	if projectPath == "" {
+		return "", fmt.Errorf("current directory is not an al project")
+	}
+	return storage.GetLocalConfigPath(projectPath), nil
+}
+
+// checkLocalConfigKeys rejects the command settings, which are ignored in a
+// project config file
+func checkLocalConfigKeys(names []string) error {
+	for _, name := range names {
+		key, err := storage.FindConfigKey(name)
+		if err != nil {
+			continue
+		}
+		if key.Command {
+			return fmt.Errorf("%s runs a command and can only be set in the global config", name)
+		}
+		if key.GlobalOnly {
+			return fmt.Errorf("%s can only be set in the global config", name)
+		}
+	}
+	return nil
+}
+
+// effectiveConfig returns the configuration of the project, falling back to
+// the defaults when the config files cannot be read
+func effectiveConfig(projectPath string) storage.Config {
+	config, _, err := storage.LoadConfigFor(projectPath)
+	if err != nil {
+		return storage.DefaultConfig()
+	}
+	return config
+}
+
+// copyToClipboard copies text with the clipboard backend configured for the project
+func copyToClipboard(projectPath, text string) error {
+	return utils.CopyToClipboardWith(effectiveConfig(projectPath).Clipboard, text)
+}
+
+// openEditor opens the editor configured for the project
+func openEditor(projectPath, path string) error {
+	return utils.OpenEditorWith(effectiveConfig(projectPath).Editor, path)
+}
+
+// encryptedLabel is shown instead of the content of encrypted values
+func encryptedLabel(config storage.Config) string {
+	if config.Locale == "en" {
+		return "**encrypted**"
+	}
+	return "**chiffrée**"
+}
+
+// applyDefaultOutput uses the configured output format when --output is not given
+func applyDefaultOutput(cmd *cobra.Command) {
+	if cmd.Flags().Changed("output") {
+		return
+	}
+
+	projectPath, err := getConfigProjectPath()
+	if err != nil {
+		return
+	}
+
+	if output := effectiveConfig(projectPath).Output; output != "table" {
+		outputFormat = output
+	}
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 && cmd.Name() == "set" {
+		key, err := storage.FindConfigKey(args[0])
+		if err == nil && key.Type == "enum" {
+			return completionCandidates(key.Values, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for _, key := range storage.ConfigKeys {
+		candidates = append(candidates, key.Name+"\t"+key.Description)
+	}
+	return completionCandidates(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	projectPath, err := getConfigProjectPath()
+	if err != nil {
+		return err
+	}
+
+	_, values, err := storage.LoadConfigFor(projectPath)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		out := make([]configOutput, 0, len(values))
+		for _, v := range values {
+			out = append(out, configOutput{Key: v.Key, Value: v.Value, Origin: v.Origin, Path: v.Path})
+		}
+		return printOutput(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	if configShowOrigin {
+		fmt.Fprintln(w, "Key\tValue\tOrigin")
+		fmt.Fprintln(w, "---\t-----\t------")
+	} else {
+		fmt.Fprintln(w, "Key\tValue")
+		fmt.Fprintln(w, "---\t-----")
+	}
+
+	for _, v := range values {
+		if configShowOrigin {
+			origin := v.Origin
+			if v.Path != "" {
+				origin = fmt.Sprintf("%s (%s)", v.Origin, v.Path)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, origin)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", v.Key, v.Value)
+		}
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	if _, err := storage.FindConfigKey(args[0]); err != nil {
+		return err
+	}
+
+	projectPath, err := getConfigProjectPath()
+	if err != nil {
+		return err
+	}
+
+	_, values, err := storage.LoadConfigFor(projectPath)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		if v.Key != args[0] {
+			continue
+		}
+		if isStructuredOutput() {
+			return printOutput(configOutput{Key: v.Key, Value: v.Value, Origin: v.Origin, Path: v.Path})
+		}
+		fmt.Println(v.Value)
+	}
+
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	name, value := args[0], args[1]
+
+	parsed, err := storage.ParseConfigValue(name, value)
+	if err != nil {
+		return err
+	}
+	if configLocal {
+		if err := checkLocalConfigKeys([]string{name}); err != nil {
+			return err
+		}
+	}
+
+	path, err := getConfigLayerPath()
+	if err != nil {
+		return err
+	}
+
+	layer, err := storage.LoadConfigLayer(path)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(parsed)
+	if err != nil {
+		return err
+	}
+	layer[name] = raw
+
+	if err := storage.SaveConfigLayer(path, layer); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s set to '%s' in %s\n", name, value, path)
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	path, err := getConfigLayerPath()
+	if err != nil {
+		return err
+	}
+
+	layer, err := storage.LoadConfigLayer(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := layer[name]; !ok {
+		if _, err := storage.FindConfigKey(name); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s is not set in %s", name, path)
+	}
+	delete(layer, name)
+
+	if err := storage.SaveConfigLayer(path, layer); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s removed from %s\n", name, path)
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	path, err := getConfigLayerPath()
+	if err != nil {
+		return err
+	}
+
+	layer, err := storage.LoadConfigLayer(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(layer, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Edit a copy so an invalid file never replaces the current one
+	tmpFile := filepath.Join(os.TempDir(), "al_config.json")
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+
+	projectPath, _ := getConfigProjectPath()
+	if err := openEditor(projectPath, tmpFile); err != nil {
+		return err
+	}
+
+	data, err = os.ReadFile(tmpFile)
+	if err != nil {
+		return err
+	}
+
+	edited := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &edited); err != nil {
+		return fmt.Errorf("invalid config, nothing saved: %w", err)
+	}
+	if err := storage.ValidateConfigLayer(edited); err != nil {
+		return fmt.Errorf("invalid config, nothing saved: %w", err)
+	}
+	if configLocal {
+		var names []string
+		for name := range edited {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if err := checkLocalConfigKeys(names); err != nil {
+			return fmt.Errorf("invalid config, nothing saved: %w", err)
+		}
+	}
+
+	if err := storage.SaveConfigLayer(path, edited); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Config saved to %s\n", path)
+	return nil
+}
@@ -0,0 +1,528 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+const envFileName = "env"
+
+type EnvVar struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	Encrypted bool      `json:"encrypted"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	envTarget    string
+	envEncrypted bool
+	envFormat    string
+	envCopy      bool
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var envCmd = &cobra.Command{
+	Use:   "env [action]",
+	Short: "Manage environment variables for projects",
+	Long: `Manage environment variables for projects. Actions: list, set, get, unset, export, exec
+With -t client:<name>, the variables are stored with the notes of the client.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all environment variables",
+	RunE:  runEnvList,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set [KEY] [VALUE]",
+	Short: "Set an environment variable",
+	Long: `Set an environment variable for the project.
+The value can be given as a second argument or with KEY=VALUE.
+With -c and no value, the value is read without echo.
+
+Example: al env set API_URL https://api.example.com
+         al env set API_TOKEN -c`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runEnvSet,
+}
+
+var envGetCmd = &cobra.Command{
+	Use:               "get [KEY]",
+	Short:             "Get an environment variable",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEnvNames,
+	RunE:              runEnvGet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:               "unset [KEY]",
+	Short:             "Remove an environment variable",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEnvNames,
+	RunE:              runEnvUnset,
+}
+
+var envExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print environment variables for eval",
+	Long: `Print the project environment variables in a format suitable for eval.
+Formats: sh, fish, dotenv
+
+Example: eval "$(al env export)"
+         al env export --format fish | source`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{shellOutputAnnotation: "true"},
+	RunE:        runEnvExport,
+}
+
+var envExecCmd = &cobra.Command{
+	Use:   "exec -- [command] [args...]",
+	Short: "Run a command with the project environment",
+	Long: `Run a command with the project environment variables injected.
+
+Example: al env exec -- npm run deploy`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEnvExec,
+}
+
+func init() {
+	// Add flags
+	envCmd.PersistentFlags().StringVarP(&envTarget, "target", "t", "", "Target project, or client:<name>")
+	envCmd.RegisterFlagCompletionFunc("target", completeTargets)
+	envSetCmd.Flags().BoolVarP(&envEncrypted, "chiffre", "c", false, "Encrypt the value")
+	envGetCmd.Flags().BoolVar(&envCopy, "cp", false, "Copy to clipboard")
+	envExportCmd.Flags().StringVarP(&envFormat, "format", "f", "sh", "Output format (sh, fish, dotenv)")
+
+	// Add subcommands
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envGetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+	envCmd.AddCommand(envExportCmd)
+	envCmd.AddCommand(envExecCmd)
+}
+
+// getEnvProjectPath returns the targeted project, or the project containing
+// the current directory
+func getEnvProjectPath() (string, error) {
+	return resolveScopePath(envTarget, false)
+}
+
+func getEnvFilePath(projectPath string) string {
+	return filepath.Join(storage.GetDataDir(projectPath), envFileName)
+}
+
+func loadEnvVars(projectPath string) (map[string]EnvVar, error) {
+	data, err := os.ReadFile(getEnvFilePath(projectPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]EnvVar), nil
+		}
+		return nil, err
+	}
+
+	var vars map[string]EnvVar
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse env file: %w", err)
+	}
+	if vars == nil {
+		vars = make(map[string]EnvVar)
+	}
+
+	return vars, nil
+}
+
+func saveEnvVars(projectPath string, vars map[string]EnvVar) error {
+	if err := ensureDataDir(storage.GetDataDir(projectPath)); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(getEnvFilePath(projectPath), data, 0600); err != nil {
+		return err
+	}
+	return refreshEnvTrust(projectPath)
+}
+
+// sortedEnvVars returns the variables ordered by name
+func sortedEnvVars(vars map[string]EnvVar) []EnvVar {
+	sorted := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// decryptEnvVars returns the plain values of the given variables. The password
+// of the first encrypted variable is reused for the others and only asked
+// again when it does not match.
+func decryptEnvVars(vars []EnvVar) (map[string]string, error) {
+	values := make(map[string]string, len(vars))
+	var passwords []string
+
+	for _, v := range vars {
+		if !v.Encrypted {
+			values[v.Name] = v.Value
+			continue
+		}
+
+		decrypted, ok := "", false
+		for _, password := range passwords {
+			if plain, err := utils.Decrypt(v.Value, password); err == nil {
+				decrypted, ok = plain, true
+				break
+			}
+		}
+
+		if !ok {
+			prompt := "Enter decryption password: "
+			if len(passwords) > 0 {
+				prompt = fmt.Sprintf("Enter decryption password for %s: ", v.Name)
+			}
+			password, err := utils.ReadPassword(prompt)
+			if err != nil {
+				return nil, err
+			}
+			plain, err := utils.Decrypt(v.Value, password)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			decrypted = plain
+			passwords = append(passwords, password)
+		}
+
+		values[v.Name] = decrypted
+	}
+
+	return values, nil
+}
+
+func findSimilarEnvVars(vars map[string]EnvVar, name string, maxDistance int) []string {
+	var names []string
+	for n := range vars {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return utils.FindSimilarStrings(name, names, maxDistance)
+}
+
+// formatEnvExport renders the variables as shell statements for the given format
+func formatEnvExport(format string, names []string, values map[string]string) (string, error) {
+	var b strings.Builder
+
+	for _, name := range names {
+		value := values[name]
+		switch format {
+		case "sh", "bash", "zsh":
+			fmt.Fprintf(&b, "export %s=%s\n", name, quoteSh(value))
+		case "fish":
+			fmt.Fprintf(&b, "set -gx %s %s\n", name, quoteFish(value))
+		case "dotenv":
+			fmt.Fprintf(&b, "%s=%s\n", name, quoteDotenv(value))
+		default:
+			return "", fmt.Errorf("unknown format '%s' (expected sh, fish or dotenv)", format)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func quoteSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+}
+
+func quoteDotenv(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func runEnvList(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		out := make([]envOutput, 0, len(vars))
+		for _, v := range sortedEnvVars(vars) {
+			out = append(out, envOutput{Name: v.Name, Value: v.Value, Encrypted: v.Encrypted, UpdatedAt: v.UpdatedAt})
+		}
+		return printOutput(out)
+	}
+
+	if len(vars) == 0 {
+		fmt.Println("No environment variables found.")
+		return nil
+	}
+
+	config := effectiveConfig(projectPath)
+	previewLength := config.PreviewLength
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tDate\tValue")
+	fmt.Fprintln(w, "----\t----\t-----")
+
+	for _, v := range sortedEnvVars(vars) {
+		date := v.UpdatedAt.Format(config.DateFormat)
+		value := encryptedLabel(config)
+		if !v.Encrypted {
+			value = utils.TruncateString(v.Value, previewLength)
+			value = strings.ReplaceAll(value, "\n", " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, date, value)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	value := ""
+	hasValue := false
+	if len(args) == 2 {
+		value, hasValue = args[1], true
+	} else if i := strings.Index(name, "="); i >= 0 {
+		name, value, hasValue = name[:i], name[i+1:], true
+	}
+
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid variable name '%s'", name)
+	}
+
+	if !hasValue {
+		if !envEncrypted {
+			return fmt.Errorf("missing value for '%s'", name)
+		}
+		value, err = utils.ReadPassword(fmt.Sprintf("Enter value for %s: ", name))
+		if err != nil {
+			return err
+		}
+	}
+
+	if envEncrypted {
+		password, err := utils.ReadPassword("Enter encryption password: ")
+		if err != nil {
+			return err
+		}
+		confirmPassword, err := utils.ReadPassword("Confirm password: ")
+		if err != nil {
+			return err
+		}
+		if password != confirmPassword {
+			return fmt.Errorf("passwords do not match")
+		}
+
+		value, err = utils.Encrypt(value, password)
+		if err != nil {
+			return err
+		}
+	}
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	_, exists := vars[name]
+	vars[name] = EnvVar{
+		Name:      name,
+		Value:     value,
+		Encrypted: envEncrypted,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := saveEnvVars(projectPath, vars); err != nil {
+		return err
+	}
+
+	if exists {
+		fmt.Printf("✓ Variable '%s' updated\n", name)
+	} else {
+		fmt.Printf("✓ Variable '%s' created\n", name)
+	}
+	return nil
+}
+
+func runEnvGet(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	v, ok := vars[name]
+	if !ok {
+		similar := findSimilarEnvVars(vars, name, 3)
+		return reportNotFound("variable", name, similar)
+	}
+
+	values, err := decryptEnvVars([]EnvVar{v})
+	if err != nil {
+		return err
+	}
+
+	if envCopy {
+		if err := copyToClipboard(projectPath, values[name]); err != nil {
+			return err
+		}
+	}
+
+	if isStructuredOutput() {
+		return printOutput(envOutput{Name: v.Name, Value: values[name], Encrypted: v.Encrypted, UpdatedAt: v.UpdatedAt})
+	}
+
+	if envCopy {
+		fmt.Println("✓ Variable copied to clipboard")
+	} else {
+		fmt.Println(values[name])
+	}
+
+	return nil
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := vars[name]; !ok {
+		similar := findSimilarEnvVars(vars, name, 3)
+		return reportNotFound("variable", name, similar)
+	}
+
+	delete(vars, name)
+
+	if err := saveEnvVars(projectPath, vars); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Variable '%s' removed\n", name)
+	return nil
+}
+
+func runEnvExport(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	sorted := sortedEnvVars(vars)
+	values, err := decryptEnvVars(sorted)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, v := range sorted {
+		names = append(names, v.Name)
+	}
+
+	output, err := formatEnvExport(envFormat, names, values)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+	return nil
+}
+
+func runEnvExec(cmd *cobra.Command, args []string) error {
+	projectPath, err := getEnvProjectPath()
+	if err != nil {
+		return err
+	}
+
+	vars, err := loadEnvVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	sorted := sortedEnvVars(vars)
+	values, err := decryptEnvVars(sorted)
+	if err != nil {
+		return err
+	}
+
+	environ := os.Environ()
+	for _, v := range sorted {
+		environ = append(environ, v.Name+"="+values[v.Name])
+	}
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = environ
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Forward the child exit code as is
+			os.Exit(exitErr.ExitCode())
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+// Shell variables used by the hook to remember what it loaded
+const (
+	envStateDir    = "AL_ENV_DIR"
+	envStateBackup = "AL_ENV_BACKUP"
+	envStateStamp  = "AL_ENV_STAMP"
+)
+
+var envHookCmd = &cobra.Command{
+	Use:   "hook [bash|zsh|fish]",
+	Short: "Print the shell integration loading project environments",
+	Long: `Print the shell integration that loads the environment of a project when
+entering its directory (for example after al go) and unloads it on leave.
+The hook runs before each prompt, so variables changed with al env set are
+reloaded too. The first time a project is entered, and whenever its env file
+changed since it was allowed, al asks whether its environment can be trusted.
+Encrypted variables are never loaded automatically.
+
+Add to ~/.bashrc:  eval "$(al env hook bash)"
+Add to ~/.zshrc:   eval "$(al env hook zsh)"
+Add to config.fish: al env hook fish | source`,
+	Args:        cobra.ExactArgs(1),
+	ValidArgs:   []string{"bash", "zsh", "fish"},
+	Annotations: map[string]string{shellOutputAnnotation: "true"},
+	RunE:        runEnvHook,
+}
+
+var envHookLoadCmd = &cobra.Command{
+	Use:         "hook-load [bash|zsh|fish]",
+	Short:       "Print the statements updating the shell environment (used by the hook)",
+	Args:        cobra.ExactArgs(1),
+	Hidden:      true,
+	Annotations: map[string]string{shellOutputAnnotation: "true"},
+	RunE:        runEnvHookLoad,
+}
+
+var envAllowCmd = &cobra.Command{
+	Use:   "allow",
+	Short: "Allow the shell hook to load the project environment",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvAllow,
+}
+
+var envDenyCmd = &cobra.Command{
+	Use:   "deny",
+	Short: "Prevent the shell hook from loading the project environment",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvDeny,
+}
+
+func init() {
+	envCmd.AddCommand(envHookCmd)
+	envCmd.AddCommand(envHookLoadCmd)
+	envCmd.AddCommand(envAllowCmd)
+	envCmd.AddCommand(envDenyCmd)
+}
+
+// getEnvTrustProject returns the project targeted by allow/deny, looking up
+// the project containing the current directory when no target is given
+func getEnvTrustProject() (string, storage.Project, error) {
+	if envTarget != "" {
+		name, project, err := storage.FindProjectByShortcut(envTarget)
+		if err != nil {
+			return "", storage.Project{}, fmt.Errorf("project '%s' not found", envTarget)
+		}
+		return name, project, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", storage.Project{}, err
+	}
+
+	name, project, err := storage.FindProjectByPath(cwd)
+	if err != nil {
+		return "", storage.Project{}, fmt.Errorf("current directory is not an al project")
+	}
+	return name, project, nil
+}
+
+func formatEnvUnset(shell string, names []string) (string, error) {
+	var b strings.Builder
+
+	for _, name := range names {
+		switch shell {
+		case "sh", "bash", "zsh":
+			fmt.Fprintf(&b, "unset %s\n", name)
+		case "fish":
+			fmt.Fprintf(&b, "set -e %s\n", name)
+		default:
+			return "", fmt.Errorf("unknown shell '%s' (expected bash, zsh or fish)", shell)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// envBackup maps the variables set by the hook to their value before loading
+// (nil when the variable was not set)
+type envBackup map[string]*string
+
+func decodeEnvBackup(encoded string) envBackup {
+	backup := envBackup{}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return backup
+	}
+	json.Unmarshal(data, &backup)
+	return backup
+}
+
+func encodeEnvBackup(backup envBackup) string {
+	data, _ := json.Marshal(backup)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func envFileStamp(projectPath string) string {
+	info, err := os.Stat(getEnvFilePath(projectPath))
+	if err != nil {
+		return "0"
+	}
+	return strconv.FormatInt(info.ModTime().UnixNano(), 10)
+}
+
+// envFileHash returns the hash of the env file of a project, empty when the
+// file does not exist
+func envFileHash(projectPath string) string {
+	data, err := os.ReadFile(getEnvFilePath(projectPath))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// refreshEnvTrust keeps an allowed project trusted after al changed its env
+// file itself
+func refreshEnvTrust(projectPath string) error {
+	trust, err := storage.LoadTrust()
+	if err != nil {
+		return err
+	}
+	if entry, ok := trust[projectPath]; ok && entry.Allowed {
+		return storage.SetTrust(projectPath, true, envFileHash(projectPath))
+	}
+	return nil
+}
+
+// checkEnvTrust tells whether the environment of the project can be loaded,
+// asking the user the first time the project is entered and again when the
+// env file changed since it was allowed (after a git pull for instance)
+func checkEnvTrust(name string, project storage.Project, count int) bool {
+	trust, err := storage.LoadTrust()
+	if err != nil {
+		return false
+	}
+
+	hash := envFileHash(project.Path)
+	entry, known := trust[project.Path]
+	if known && (!entry.Allowed || entry.Hash == hash) {
+		return entry.Allowed
+	}
+	changed := known && entry.Allowed
+
+	if !utils.IsTerminal(os.Stdin) {
+		if changed {
+			fmt.Fprintf(os.Stderr, "al: environment of '%s' changed since it was allowed, run 'al env allow' to load it\n", name)
+		} else {
+			fmt.Fprintf(os.Stderr, "al: environment of '%s' is not trusted, run 'al env allow' to load it\n", name)
+		}
+		return false
+	}
+
+	prompt := fmt.Sprintf("al: load the %d environment variable(s) of project '%s'?", count, name)
+	if changed {
+		prompt = fmt.Sprintf("al: the environment of project '%s' changed since it was allowed, load its %d variable(s)?", name, count)
+	}
+	allowed := utils.AskConfirmationOn(os.Stderr, prompt)
+	if err := storage.SetTrust(project.Path, allowed, hash); err != nil {
+		fmt.Fprintf(os.Stderr, "al: %v\n", err)
+	}
+	if !allowed {
+		fmt.Fprintln(os.Stderr, "al: environment not loaded, run 'al env allow' to change your mind")
+	}
+	return allowed
+}
+
+func runEnvHook(cmd *cobra.Command, args []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Printf(`_al_hook() {
+  local previous_exit_status=$?
+  eval "$(%[1]s env hook-load bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_al_hook;"* ]]; then
+  PROMPT_COMMAND="_al_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`, quoteSh(executable))
+	case "zsh":
+		fmt.Printf(`_al_hook() {
+  eval "$(%[1]s env hook-load zsh)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _al_hook
+_al_hook
+`, quoteSh(executable))
+	case "fish":
+		fmt.Printf(`function __al_hook --on-event fish_prompt
+    %[1]s env hook-load fish | source
+end
+__al_hook
+`, quoteFish(executable))
+	default:
+		return fmt.Errorf("unknown shell '%s' (expected bash, zsh or fish)", args[0])
+	}
+
+	return nil
+}
+
+func runEnvHookLoad(cmd *cobra.Command, args []string) error {
+	shell := args[0]
+	if shell != "bash" && shell != "zsh" && shell != "fish" {
+		return fmt.Errorf("unknown shell '%s' (expected bash, zsh or fish)", shell)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	loadedDir := os.Getenv(envStateDir)
+	name, project, findErr := storage.FindProjectByPath(cwd)
+
+	// Nothing to do while staying in the same unchanged project
+	if findErr == nil && project.Path == loadedDir && envFileStamp(project.Path) == os.Getenv(envStateStamp) {
+		return nil
+	}
+	if findErr != nil && loadedDir == "" {
+		return nil
+	}
+
+	backup := decodeEnvBackup(os.Getenv(envStateBackup))
+
+	// Restore the variables of the previous project
+	var out strings.Builder
+	var restored []string
+	restore := make(map[string]string)
+	for varName, previous := range backup {
+		if previous == nil {
+			restored = append(restored, varName)
+		} else {
+			restore[varName] = *previous
+		}
+	}
+	sort.Strings(restored)
+	unset, err := formatEnvUnset(shell, restored)
+	if err != nil {
+		return err
+	}
+	out.WriteString(unset)
+
+	var restoredNames []string
+	for varName := range restore {
+		restoredNames = append(restoredNames, varName)
+	}
+	sort.Strings(restoredNames)
+	exports, err := formatEnvExport(shell, restoredNames, restore)
+	if err != nil {
+		return err
+	}
+	out.WriteString(exports)
+
+	if loadedDir != "" && (findErr != nil || project.Path != loadedDir) {
+		fmt.Fprintln(os.Stderr, "al: environment unloaded")
+	}
+
+	state := map[string]string{}
+	newBackup := envBackup{}
+
+	if findErr == nil {
+		vars, err := loadEnvVars(project.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "al: %v\n", err)
+			vars = nil
+		}
+
+		if len(vars) > 0 && checkEnvTrust(name, project, len(vars)) {
+			values := make(map[string]string)
+			var names []string
+			skipped := 0
+			for _, v := range sortedEnvVars(vars) {
+				if v.Encrypted {
+					skipped++
+					continue
+				}
+				names = append(names, v.Name)
+				values[v.Name] = v.Value
+
+				// Keep the value the shell had before any al project was loaded
+				if previous, ok := backup[v.Name]; ok {
+					newBackup[v.Name] = previous
+				} else if current, ok := os.LookupEnv(v.Name); ok {
+					newBackup[v.Name] = &current
+				} else {
+					newBackup[v.Name] = nil
+				}
+			}
+
+			exports, err := formatEnvExport(shell, names, values)
+			if err != nil {
+				return err
+			}
+			out.WriteString(exports)
+
+			fmt.Fprintf(os.Stderr, "al: loaded environment of '%s' (%s)\n", name, strings.Join(names, " "))
+			if skipped > 0 {
+				fmt.Fprintf(os.Stderr, "al: %d encrypted variable(s) skipped, use 'al env exec' to use them\n", skipped)
+			}
+		}
+
+		// Remember the project even when nothing was loaded so the trust
+		// prompt is not repeated on every prompt
+		state[envStateDir] = project.Path
+		state[envStateStamp] = envFileStamp(project.Path)
+		state[envStateBackup] = encodeEnvBackup(newBackup)
+	}
+
+	if len(state) == 0 {
+		unset, err := formatEnvUnset(shell, []string{envStateBackup, envStateDir, envStateStamp})
+		if err != nil {
+			return err
+		}
+		out.WriteString(unset)
+	} else {
+		exports, err := formatEnvExport(shell, []string{envStateBackup, envStateDir, envStateStamp}, state)
+		if err != nil {
+			return err
+		}
+		out.WriteString(exports)
+	}
+
+	fmt.Print(out.String())
+	return nil
+}
+
+func runEnvAllow(cmd *cobra.Command, args []string) error {
+	name, project, err := getEnvTrustProject()
+	if err != nil {
+		return err
+	}
+
+	if err := storage.SetTrust(project.Path, true, envFileHash(project.Path)); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Environment of '%s' allowed\n", name)
+	return nil
+}
+
+func runEnvDeny(cmd *cobra.Command, args []string) error {
+	name, project, err := getEnvTrustProject()
+	if err != nil {
+		return err
+	}
+
+	if err := storage.SetTrust(project.Path, false, envFileHash(project.Path)); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Environment of '%s' denied\n", name)
+	return nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alex/al/storage"
+)
+
+func TestEnvHookScripts(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "bash", want: []string{"env hook-load bash)\"", `PROMPT_COMMAND="_al_hook`}},
+		{shell: "zsh", want: []string{"env hook-load zsh)\"", "add-zsh-hook precmd _al_hook"}},
+		{shell: "fish", want: []string{"env hook-load fish | source", "--on-event fish_prompt"}},
+	}
+
+	for _, tt := range tests {
+		out := captureStdout(t, func() {
+			if err := runEnvHook(envHookCmd, []string{tt.shell}); err != nil {
+				t.Fatal(err)
+			}
+		})
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s hook does not contain %q:\n%s", tt.shell, want, out)
+			}
+		}
+	}
+
+	if err := runEnvHook(envHookCmd, []string{"tcsh"}); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
+
+func TestQuoteShells(t *testing.T) {
+	tests := []struct {
+		in   string
+		sh   string
+		fish string
+	}{
+		{in: "/usr/bin/al", sh: `'/usr/bin/al'`, fish: `'/usr/bin/al'`},
+		{in: "/opt/My Apps/al", sh: `'/opt/My Apps/al'`, fish: `'/opt/My Apps/al'`},
+		{in: "it's", sh: `'it'\''s'`, fish: `'it\'s'`},
+		{in: `C:\al`, sh: `'C:\al'`, fish: `'C:\\al'`},
+		{in: "$HOME `id`", sh: "'$HOME `id`'", fish: "'$HOME `id`'"},
+	}
+
+	for _, tt := range tests {
+		if got := quoteSh(tt.in); got != tt.sh {
+			t.Errorf("quoteSh(%q) = %s, want %s", tt.in, got, tt.sh)
+		}
+		if got := quoteFish(tt.in); got != tt.fish {
+			t.Errorf("quoteFish(%q) = %s, want %s", tt.in, got, tt.fish)
+		}
+	}
+}
+
+func TestEnvBackupRoundTrip(t *testing.T) {
+	previous := "old value"
+	backup := envBackup{"API_URL": &previous, "NEW_VAR": nil}
+
+	decoded := decodeEnvBackup(encodeEnvBackup(backup))
+	if len(decoded) != 2 || decoded["NEW_VAR"] != nil || decoded["API_URL"] == nil || *decoded["API_URL"] != previous {
+		t.Errorf("decoded %v", decoded)
+	}
+
+	for _, broken := range []string{"", "not base64!", "bm90IGpzb24="} {
+		if got := decodeEnvBackup(broken); len(got) != 0 {
+			t.Errorf("decodeEnvBackup(%q) = %v, want an empty backup", broken, got)
+		}
+	}
+}
+
+// setupEnvProject registers a project holding vars and returns its path
+func setupEnvProject(t *testing.T, vars map[string]EnvVar) string {
+	t.Helper()
+	setupTestHome(t)
+	project := t.TempDir()
+	if err := storage.SaveProjects(map[string]storage.Project{"web": {Path: project, Shortcuts: []string{"web"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveEnvVars(project, vars); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestCheckEnvTrust(t *testing.T) {
+	project := setupEnvProject(t, map[string]EnvVar{"A": {Name: "A", Value: "1"}})
+	p := storage.Project{Path: project}
+	hash := envFileHash(project)
+	if hash == "" {
+		t.Fatal("no hash for an existing env file")
+	}
+
+	// Without a terminal an unknown project is never trusted
+	if checkEnvTrust("web", p, 1) {
+		t.Error("unknown project trusted")
+	}
+
+	if err := storage.SetTrust(project, true, hash); err != nil {
+		t.Fatal(err)
+	}
+	if !checkEnvTrust("web", p, 1) {
+		t.Error("allowed project not trusted")
+	}
+
+	// al env set keeps the project trusted
+	if err := saveEnvVars(project, map[string]EnvVar{"A": {Name: "A", Value: "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !checkEnvTrust("web", p, 1) {
+		t.Error("project no longer trusted after al env set")
+	}
+
+	// Any other change asks again
+	if err := os.WriteFile(getEnvFilePath(project), []byte(`{"A":{"name":"A","value":"evil"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if checkEnvTrust("web", p, 1) {
+		t.Error("project trusted after its env file changed")
+	}
+
+	if err := storage.SetTrust(project, false, envFileHash(project)); err != nil {
+		t.Fatal(err)
+	}
+	if checkEnvTrust("web", p, 1) {
+		t.Error("denied project trusted")
+	}
+	if err := refreshEnvTrust(project); err != nil {
+		t.Fatal(err)
+	}
+	if trust, _ := storage.LoadTrust(); trust[project].Allowed {
+		t.Error("refreshEnvTrust allowed a denied project")
+	}
+}
+
+// applyShellExports sets the variables exported by sh statements, as the
+// shell evaluating them would, and returns them
+func applyShellExports(t *testing.T, out string) map[string]string {
+	t.Helper()
+	exported := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "export "):
+			name, quoted, _ := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			value := strings.ReplaceAll(strings.Trim(quoted, "'"), `'\''`, "'")
+			exported[name] = value
+			os.Setenv(name, value)
+		case strings.HasPrefix(line, "unset "):
+			os.Unsetenv(strings.TrimPrefix(line, "unset "))
+		case line != "":
+			t.Fatalf("unexpected statement %q", line)
+		}
+	}
+	return exported
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func TestEnvHookLoadBackupAndRestore(t *testing.T) {
+	project := setupEnvProject(t, map[string]EnvVar{
+		"API_URL": {Name: "API_URL", Value: "https://api.example.com"},
+		"QUOTED":  {Name: "QUOTED", Value: "it's"},
+		"TOKEN":   {Name: "TOKEN", Value: "ciphertext", Encrypted: true},
+	})
+	if err := storage.SetTrust(project, true, envFileHash(project)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shell state before entering the project
+	for _, name := range []string{"API_URL", "QUOTED", "TOKEN", envStateDir, envStateBackup, envStateStamp} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	os.Setenv("API_URL", "shell value")
+
+	hookLoad := func() string {
+		return captureStdout(t, func() {
+			if err := runEnvHookLoad(envHookLoadCmd, []string{"bash"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	chdir(t, project)
+	exported := applyShellExports(t, hookLoad())
+	if exported["API_URL"] != "https://api.example.com" || exported["QUOTED"] != "it's" {
+		t.Errorf("project variables not exported: %v", exported)
+	}
+	if _, ok := exported["TOKEN"]; ok {
+		t.Error("encrypted variable loaded")
+	}
+	if exported[envStateDir] != project {
+		t.Errorf("%s = %q, want %q", envStateDir, exported[envStateDir], project)
+	}
+	backup := decodeEnvBackup(exported[envStateBackup])
+	if backup["API_URL"] == nil || *backup["API_URL"] != "shell value" || backup["QUOTED"] != nil {
+		t.Errorf("backup = %v", backup)
+	}
+
+	// Nothing is printed while staying in the unchanged project
+	if out := hookLoad(); out != "" {
+		t.Errorf("unexpected statements on the next prompt: %q", out)
+	}
+
+	// Leaving the project restores the shell
+	chdir(t, t.TempDir())
+	applyShellExports(t, hookLoad())
+	if got := os.Getenv("API_URL"); got != "shell value" {
+		t.Errorf("API_URL = %q after leaving, want the shell value", got)
+	}
+	for _, name := range []string{"QUOTED", envStateDir, envStateBackup, envStateStamp} {
+		if value, ok := os.LookupEnv(name); ok {
+			t.Errorf("%s = %q left after leaving the project", name, value)
+		}
+	}
+}
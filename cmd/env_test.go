@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// envTestValues are the values whose quoting is checked in every format
+var envTestValues = []string{
+	"plain",
+	"",
+	"with space",
+	"it's",
+	`say "hi"`,
+	`C:\path\to`,
+	"$HOME `id` $(id)",
+	"line 1\nline 2",
+	"#not a comment",
+}
+
+func TestFormatEnvExportSh(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	for _, value := range envTestValues {
+		out, err := formatEnvExport("sh", []string{"AL_TEST"}, map[string]string{"AL_TEST": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := exec.Command(sh, "-c", out+`printf %s "$AL_TEST"`).Output()
+		if err != nil {
+			t.Fatalf("sh rejected %q: %v", out, err)
+		}
+		if string(got) != value {
+			t.Errorf("sh read %q back as %q", value, got)
+		}
+	}
+}
+
+func TestFormatEnvExportFish(t *testing.T) {
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		t.Skip("fish not found")
+	}
+
+	for _, value := range envTestValues {
+		out, err := formatEnvExport("fish", []string{"AL_TEST"}, map[string]string{"AL_TEST": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := exec.Command(fish, "--no-config", "-c", out+`printf %s "$AL_TEST"`).Output()
+		if err != nil {
+			t.Fatalf("fish rejected %q: %v", out, err)
+		}
+		if string(got) != value {
+			t.Errorf("fish read %q back as %q", value, got)
+		}
+	}
+}
+
+func TestFormatEnvExport(t *testing.T) {
+	names := []string{"A", "B", "C"}
+	values := map[string]string{"A": "x y", "B": "it's", "C": "line 1\n\"2\" \\ $x"}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "sh", want: "export A='x y'\nexport B='it'\\''s'\nexport C='line 1\n\"2\" \\ $x'\n"},
+		{format: "zsh", want: "export A='x y'\nexport B='it'\\''s'\nexport C='line 1\n\"2\" \\ $x'\n"},
+		{format: "fish", want: "set -gx A 'x y'\nset -gx B 'it\\'s'\nset -gx C 'line 1\n\"2\" \\\\ $x'\n"},
+		{format: "dotenv", want: "A=\"x y\"\nB=\"it's\"\nC=\"line 1\\n\\\"2\\\" \\\\ $x\"\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := formatEnvExport(tt.format, names, values)
+		if err != nil || got != tt.want {
+			t.Errorf("%s export = %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+
+	if _, err := formatEnvExport("powershell", names, values); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	// Nothing to export is not an error, whatever the format
+	if got, err := formatEnvExport("powershell", nil, nil); got != "" || err != nil {
+		t.Errorf("empty export = %q, %v", got, err)
+	}
+}
+
+func TestQuoteDotenv(t *testing.T) {
+	tests := map[string]string{
+		"plain":          `"plain"`,
+		"":               `""`,
+		`say "hi"`:       `"say \"hi\""`,
+		`C:\path`:        `"C:\\path"`,
+		"line 1\nline 2": `"line 1\nline 2"`,
+		"it's $HOME":     `"it's $HOME"`,
+	}
+	for in, want := range tests {
+		if got := quoteDotenv(in); got != want {
+			t.Errorf("quoteDotenv(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestEnvNamePattern(t *testing.T) {
+	for _, name := range []string{"API_URL", "_private", "a1"} {
+		if !envNamePattern.MatchString(name) {
+			t.Errorf("%q rejected", name)
+		}
+	}
+	for _, name := range []string{"", "1ABC", "API-URL", "A B", "A=B", "é"} {
+		if envNamePattern.MatchString(name) {
+			t.Errorf("%q accepted", name)
+		}
+	}
+}
+
+func TestRunEnvSet(t *testing.T) {
+	setupEnvProject(t, nil)
+	t.Cleanup(func() { envTarget = "" })
+	envTarget = "web"
+
+	out := captureStdout(t, func() {
+		for _, args := range [][]string{{"API_URL", "https://a"}, {"API_URL=https://b=c"}, {"EMPTY="}} {
+			if err := runEnvSet(envSetCmd, args); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if !strings.Contains(out, "Variable 'API_URL' created") || !strings.Contains(out, "Variable 'API_URL' updated") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	project, _ := resolveTarget("web")
+	vars, err := loadEnvVars(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["API_URL"].Value != "https://b=c" || vars["EMPTY"].Value != "" || len(vars) != 2 {
+		t.Errorf("vars = %+v", vars)
+	}
+
+	if err := runEnvSet(envSetCmd, []string{"API-URL", "x"}); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+	if err := runEnvSet(envSetCmd, []string{"TOKEN"}); err == nil || !strings.Contains(err.Error(), "missing value") {
+		t.Errorf("missing value: %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-
 	"github.com/alex/al/storage"
 	"github.com/alex/al/utils"
 	"github.com/spf13/cobra"
@@ -15,9 +14,12 @@ var goCmd = &cobra.Command{
 	Long: `Copy the project directory path to clipboard using its name or any of its shortcuts.
 	
 Example: al go myproject
-Then: cd <paste>`,
-	Args: cobra.ExactArgs(1),
-	RunE: runGo,
+Then: cd <paste>
+
+With --output, the project is printed instead of being copied.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectArg,
+	RunE:              runGo,
 }
 
 func runGo(cmd *cobra.Command, args []string) error {
@@ -32,15 +34,18 @@ func runGo(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("project '%s' not found", shortcut)
 		}
 
-		// Collect all possible shortcuts
+		// Collect all possible shortcuts, archived projects are not suggested
 		var allShortcuts []string
 		for projName, proj := range projects {
+			if proj.Archived() {
+				continue
+			}
 			allShortcuts = append(allShortcuts, projName)
 			allShortcuts = append(allShortcuts, proj.Shortcuts...)
 		}
 
 		similar := utils.FindSimilarStrings(shortcut, allShortcuts, 3)
-		if len(similar) > 0 {
+		if len(similar) > 0 && !isStructuredOutput() {
 			fmt.Printf("Project '%s' not found. Did you mean:\n", shortcut)
 			for _, s := range similar {
 				fmt.Printf("  - %s\n", s)
@@ -48,11 +53,16 @@ func runGo(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("project not found")
 		}
 
-		return fmt.Errorf("project '%s' not found", shortcut)
+		return &notFoundError{kind: "project", name: shortcut, suggestions: similar}
+	}
+
+	// Scripts only need the project, the clipboard is left untouched
+	if isStructuredOutput() {
+		return printOutput(newProjectOutput(name, project))
 	}
 
 	// Copy path to clipboard
-	if err := utils.CopyToClipboard(project.Path); err != nil {
+	if err := copyToClipboard(project.Path, project.Path); err != nil {
 		return fmt.Errorf("failed to copy to clipboard: %w", err)
 	}
 
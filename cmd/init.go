@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/alex/al/storage"
 	"github.com/spf13/cobra"
@@ -17,10 +18,21 @@ var initCmd = &cobra.Command{
 The directory name is automatically added as a shortcut.
 Additional shortcuts can be specified separated by pipes (|).
 
-Example: al init bar|mad|tes`,
+With --with-template, the notes and links of a template bundle are created
+in the new project (see al template).
+
+Example: al init bar|mad|tes
+         al init acme --with-template onboarding`,
 	RunE: runInit,
 }
 
+var initTemplate string
+
+func init() {
+	initCmd.Flags().StringVar(&initTemplate, "with-template", "", "Create the notes and links of a template bundle")
+	initCmd.RegisterFlagCompletionFunc("with-template", completeBundleTemplates)
+}
+
 func runInit(cmd *cobra.Command, args []string) error {
 	// Ensure global directory exists
 	if err := storage.EnsureGlobalDir(); err != nil {
@@ -50,6 +62,17 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Load the bundle first so that a bad template leaves nothing behind
+	var bundle *templateBundle
+	if initTemplate != "" {
+		if bundle, err = loadTemplateBundle(initTemplate); err != nil {
+			if os.IsNotExist(err) {
+				return reportTemplateNotFound(initTemplate, templateKindBundle)
+			}
+			return err
+		}
+	}
+
 	// Check if project already exists
 	projects, err := storage.LoadProjects()
 	if err != nil {
@@ -93,9 +116,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Add project to global registry
+	now := time.Now()
 	projects[dirName] = storage.Project{
 		Path:      cwd,
 		Shortcuts: shortcuts,
+		Status:    storage.ProjectActive,
+		CreatedAt: &now,
 	}
 
 	if err := storage.SaveProjects(projects); err != nil {
@@ -105,5 +131,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("✓ Initialized project '%s' in %s\n", dirName, cwd)
 	fmt.Printf("✓ Shortcuts: %s\n", strings.Join(shortcuts, ", "))
 
+	if bundle != nil {
+		if err := applyTemplateBundle(cwd, bundle); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/alex/al/storage"
 	"github.com/alex/al/utils"
@@ -14,9 +17,16 @@ import (
 )
 
 type Link struct {
-	Name     string   `json:"name"`
-	URL      string   `json:"url"`
-	Keywords []string `json:"keywords"`
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	Keywords    []string   `json:"keywords"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Favicon     string     `json:"favicon,omitempty"`
+	Check       *LinkCheck `json:"check,omitempty"`
+	Pinned      bool       `json:"pinned,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 var (
@@ -26,12 +36,21 @@ var (
 	linkAddKeywords  string
 	linkResetKeywords string
 	linkCopy         bool
+	linkOpenAll      bool
+	linkOpenKeyword  string
+	linkOpenPrint    bool
+	linkFetch        bool
+	linkSearch       string
+	linkSetVars      []string
+	linkGlobal       bool
+	linkListAll      bool
+	linkListOpts     listOptions
 )
 
 var linkCmd = &cobra.Command{
 	Use:   "link [action]",
 	Short: "Manage links for projects",
-	Long:  `Manage links for projects. Actions: list, add, get, edit, remove`,
+	Long:  `Manage links for projects. Actions: list, add, get, edit, remove, open, mv, cp, check, import, export, vars, pin, unpin`,
 }
 
 var linkListCmd = &cobra.Command{
@@ -43,81 +62,144 @@ var linkListCmd = &cobra.Command{
 var linkAddCmd = &cobra.Command{
 	Use:   "add [#name]",
 	Short: "Add a new link",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runLinkAdd,
+	Long: `Add a new link. With --fetch, the page is downloaded to store its title,
+description and favicon, and the name can be omitted: one is suggested from
+the page title.
+
+Example: al link add #grafana -u https://grafana.acme.io -k monitoring
+         al link add -u https://grafana.acme.io --fetch`,
+	Args: cobra.RangeArgs(0, 1),
+	RunE: runLinkAdd,
 }
 
 var linkGetCmd = &cobra.Command{
-	Use:   "get [#name/#keyword]",
+	Use:   "get [#name/#keyword] [values...]",
 	Short: "Get a link",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runLinkGet,
+	Long: `Get a link. The {{variables}} of the URL are filled with --set, then with
+the values given after the name, then with the defaults of 'al link vars'.
+
+Example: al link get #jira ABC-12
+         al link get #grafana --set env=staging --copy`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeLinkNames,
+	RunE:              runLinkGet,
 }
 
 var linkEditCmd = &cobra.Command{
-	Use:   "edit [#name/#keyword]",
-	Short: "Edit a link",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runLinkEdit,
+	Use:               "edit [#name/#keyword]",
+	Short:             "Edit a link",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLinkNames,
+	RunE:              runLinkEdit,
 }
 
 var linkRemoveCmd = &cobra.Command{
-	Use:   "remove [#name/#keyword]",
-	Short: "Remove a link",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runLinkRemove,
+	Use:               "remove [#name/#keyword]",
+	Short:             "Remove a link",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLinkNames,
+	RunE:              runLinkRemove,
+}
+
+var linkOpenCmd = &cobra.Command{
+	Use:   "open [#name/#keyword] [values...]",
+	Short: "Open links in the browser",
+	Long: `Open a link in the browser. When a keyword matches several links, all of
+them are opened. The browser is the 'browser' setting, or xdg-open.
+
+Example: al link open #grafana
+         al link open --all --keyword monitoring
+         al link open #docs --print
+         al link open #jira ABC-12`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeLinkNames,
+	RunE:              runLinkOpen,
 }
 
 func init() {
 	// Add flags
 	linkCmd.PersistentFlags().StringVarP(&linkTarget, "target", "t", "", "Target project")
+	linkCmd.RegisterFlagCompletionFunc("target", completeTargets)
+	linkCmd.PersistentFlags().BoolVarP(&linkGlobal, "global", "g", false, "Use the global links (outside of any project)")
 	
 	linkAddCmd.Flags().StringVarP(&linkURL, "url", "u", "", "Link URL (required)")
 	linkAddCmd.Flags().StringVarP(&linkKeywords, "keywords", "k", "", "Keywords separated by |")
+	linkAddCmd.Flags().BoolVar(&linkFetch, "fetch", false, "Fetch the page title, description and favicon")
 	linkAddCmd.MarkFlagRequired("url")
 
+	linkListCmd.Flags().BoolVar(&linkListAll, "all-projects", false, "List the links of every project and client")
+	addListFlags(linkListCmd, &linkListOpts, false)
+	linkListCmd.Flags().StringVarP(&linkSearch, "search", "s", "", "Only list links whose name, URL, keywords, title or description contain this text")
+
 	linkGetCmd.Flags().BoolVarP(&linkCopy, "copy", "c", false, "Copy to clipboard")
+	linkGetCmd.Flags().StringArrayVar(&linkSetVars, "set", nil, "Value of a URL variable (name=value)")
 
 	linkEditCmd.Flags().StringVarP(&linkURL, "url", "u", "", "New URL")
 	linkEditCmd.Flags().StringVarP(&linkAddKeywords, "add_keyword", "a", "", "Add keywords")
 	linkEditCmd.Flags().StringVarP(&linkResetKeywords, "reset_keyword", "r", "", "Reset keywords")
+
+	linkOpenCmd.Flags().BoolVarP(&linkOpenAll, "all", "a", false, "Open all links")
+	linkOpenCmd.Flags().StringVarP(&linkOpenKeyword, "keyword", "k", "", "Only open links with this keyword (with --all)")
+	linkOpenCmd.Flags().BoolVarP(&linkOpenPrint, "print", "p", false, "Print the URLs instead of opening them")
+	linkOpenCmd.Flags().StringArrayVar(&linkSetVars, "set", nil, "Value of a URL variable (name=value)")
 	
 
 	// Add subcommands
 	linkCmd.AddCommand(linkListCmd)
+	markAllProjectsExclusive(linkListCmd)
 	linkCmd.AddCommand(linkAddCmd)
 	linkCmd.AddCommand(linkGetCmd)
 	linkCmd.AddCommand(linkEditCmd)
 	linkCmd.AddCommand(linkRemoveCmd)
+	linkCmd.AddCommand(linkOpenCmd)
 }
 
+// getLinkProjectPath returns the project of the links, or an empty path for
+// the global links
 func getLinkProjectPath() (string, error) {
-	if linkTarget != "" {
-		_, project, err := storage.FindProjectByShortcut(linkTarget)
-		if err != nil {
-			return "", fmt.Errorf("project '%s' not found", linkTarget)
-		}
-		return project.Path, nil
-	}
-
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	return cwd, nil
+	return resolveScopePath(linkTarget, linkGlobal)
 }
 
 func getLinksDir(projectPath string) string {
-	return filepath.Join(storage.GetLocalDir(projectPath), "links")
+	return filepath.Join(storage.GetDataDir(projectPath), "links")
 }
 
+// getLinkFilePath returns the file of a link, named after its slugified name
 func getLinkFilePath(projectPath, linkName string) string {
-	linkName = strings.TrimPrefix(linkName, "#")
-	return filepath.Join(getLinksDir(projectPath), linkName+".json")
+	return itemFilePath(getLinksDir(projectPath), linkName)
 }
 
+// loadLink loads the link named linkName. Another link stored in the same
+// file is reported as a nameCollisionError.
 func loadLink(projectPath, linkName string) (*Link, error) {
-	linkPath := getLinkFilePath(projectPath, linkName)
+	link, err := loadLinkFile(projectPath, linkName)
+	if err != nil {
+		return nil, err
+	}
+	if !sameItemName(link.Name, linkName) {
+		return nil, &nameCollisionError{kind: "link", name: strings.TrimPrefix(linkName, "#"), stored: link.Name}
+	}
+	return link, nil
+}
+
+// loadLinkFile loads the link stored in the file of linkName, whatever its
+// name: it tells whether a new link can use that file
+func loadLinkFile(projectPath, linkName string) (*Link, error) {
+	return readLinkFile(getLinkFilePath(projectPath, linkName))
+}
+
+// reportLinkNotFound reports a link that loadLink could not load, with the
+// similar names or the link sharing its file
+func reportLinkNotFound(projectPath, linkName string, err error) error {
+	var collision *nameCollisionError
+	if errors.As(err, &collision) {
+		return collision
+	}
+	similar, _ := findSimilarLinks(projectPath, linkName, 3)
+	return reportNotFound("link", linkName, similar)
+}
+
+func readLinkFile(linkPath string) (*Link, error) {
 	data, err := os.ReadFile(linkPath)
 	if err != nil {
 		return nil, err
@@ -132,6 +214,10 @@ func loadLink(projectPath, linkName string) (*Link, error) {
 }
 
 func saveLink(projectPath string, link *Link) error {
+	if err := ensureDataDir(getLinksDir(projectPath)); err != nil {
+		return err
+	}
+
 	linkPath := getLinkFilePath(projectPath, link.Name)
 	data, err := json.MarshalIndent(link, "", "  ")
 	if err != nil {
@@ -158,8 +244,8 @@ func listLinks(projectPath string) ([]Link, error) {
 			continue
 		}
 
-		linkName := strings.TrimSuffix(entry.Name(), ".json")
-		link, err := loadLink(projectPath, linkName)
+		linkPath := filepath.Join(linksDir, entry.Name())
+		link, err := readLinkFile(linkPath)
 		if err != nil {
 			continue
 		}
@@ -197,6 +283,39 @@ func findLinkByNameOrKeyword(projectPath, identifier string) (*Link, error) {
 	return nil, fmt.Errorf("link not found")
 }
 
+// findLinksByNameOrKeyword returns the link named identifier, or every link
+// having identifier as keyword
+func findLinksByNameOrKeyword(projectPath, identifier string) ([]Link, error) {
+	identifier = strings.TrimPrefix(identifier, "#")
+	identifier = strings.ToLower(identifier)
+
+	links, err := listLinks(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		if strings.ToLower(link.Name) == identifier {
+			return []Link{link}, nil
+		}
+	}
+
+	var matches []Link
+	for _, link := range links {
+		for _, keyword := range link.Keywords {
+			if strings.ToLower(keyword) == identifier {
+				matches = append(matches, link)
+				break
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("link not found")
+	}
+	return matches, nil
+}
+
 func findSimilarLinks(projectPath, linkIdentifier string, maxDistance int) ([]string, error) {
 	links, err := listLinks(projectPath)
 	if err != nil {
@@ -213,32 +332,99 @@ func findSimilarLinks(projectPath, linkIdentifier string, maxDistance int) ([]st
 }
 
 func runLinkList(cmd *cobra.Command, args []string) error {
-	projectPath, err := getLinkProjectPath()
+	projects, err := getNamedProjects(linkListAll, getLinkProjectPath)
 	if err != nil {
 		return err
 	}
 
-	links, err := listLinks(projectPath)
+	type listedLink struct {
+		project string
+		link    Link
+	}
+
+	// Listings across projects use the global settings
+	configPath := ""
+	if !linkListAll {
+		configPath = projects[0].path
+	}
+	config := effectiveConfig(configPath)
+
+	var all []listedLink
+	var entries []listEntry
+	for _, project := range projects {
+		links, err := listLinks(project.path)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if linkSearch != "" && !link.matches(linkSearch) {
+				continue
+			}
+			all = append(all, listedLink{project: project.name, link: link})
+			entries = append(entries, listEntry{
+				project:   project.name,
+				name:      link.Name,
+				createdAt: link.CreatedAt,
+				updatedAt: link.UpdatedAt,
+				size:      len(link.URL),
+				pinned:    link.Pinned,
+			})
+		}
+	}
+
+	selected, err := linkListOpts.selectEntries(entries, config.Sort)
 	if err != nil {
 		return err
 	}
+	listed := make([]listedLink, 0, len(selected))
+	for _, i := range selected {
+		listed = append(listed, all[i])
+	}
+
+	if isStructuredOutput() {
+		if linkListAll {
+			out := make([]projectLinkOutput, 0, len(listed))
+			for _, l := range listed {
+				out = append(out, projectLinkOutput{Project: l.project, linkOutput: newLinkOutput(l.link)})
+			}
+			return printOutput(out)
+		}
+		out := make([]linkOutput, 0, len(listed))
+		for _, l := range listed {
+			out = append(out, newLinkOutput(l.link))
+		}
+		return printOutput(out)
+	}
 
-	if len(links) == 0 {
+	if len(listed) == 0 {
 		fmt.Println("No links found.")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Name\tLink\tKeywords")
-	fmt.Fprintln(w, "----\t----\t--------")
+	previewLength := config.PreviewLength
 
-	for _, link := range links {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	if linkListAll {
+		fmt.Fprintln(w, "Project\tName\tLink\tKeywords\tTitle")
+		fmt.Fprintln(w, "-------\t----\t----\t--------\t-----")
+	} else {
+		fmt.Fprintln(w, "Name\tLink\tKeywords\tTitle")
+		fmt.Fprintln(w, "----\t----\t--------\t-----")
+	}
+
+	for _, l := range listed {
+		link := l.link
 		keywords := strings.Join(link.Keywords, ", ")
-		fmt.Fprintf(w, "%s\t%s\t%s\n", link.Name, link.URL, keywords)
+		title := utils.TruncateString(link.Title, previewLength)
+		if linkListAll {
+			fmt.Fprintf(w, "%s\t", l.project)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", link.Name, link.URL, keywords, title)
 	}
 
 	w.Flush()
-	return nil
+	return utils.Page(buf.String())
 }
 
 func runLinkAdd(cmd *cobra.Command, args []string) error {
@@ -247,11 +433,35 @@ func runLinkAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	linkName := strings.TrimPrefix(args[0], "#")
+	if len(args) == 0 && !linkFetch {
+		return fmt.Errorf("a link name is required (or use --fetch to suggest one)")
+	}
 
-	// Check if link already exists
-	if _, err := loadLink(projectPath, linkName); err == nil {
-		return fmt.Errorf("link '%s' already exists", linkName)
+	linkName := ""
+	if len(args) == 1 {
+		linkName = strings.TrimPrefix(args[0], "#")
+		if err := validateName("link", linkName); err != nil {
+			return err
+		}
+
+		// Check if link already exists
+		if existing, err := loadLinkFile(projectPath, linkName); err == nil {
+			return itemExistsError("link", linkName, existing.Name)
+		}
+	}
+
+	meta := &pageMetadata{}
+	if linkFetch {
+		fetched, err := linkPageFetcher.Fetch(linkURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not fetch %s: %v\n", linkURL, err)
+		} else {
+			meta = fetched
+		}
+	}
+
+	if linkName == "" {
+		linkName = uniqueLinkName(projectPath, suggestLinkName(meta, linkURL))
 	}
 
 	// Parse keywords
@@ -267,9 +477,14 @@ func runLinkAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	link := &Link{
-		Name:     linkName,
-		URL:      linkURL,
-		Keywords: keywords,
+		Name:        linkName,
+		URL:         linkURL,
+		Keywords:    keywords,
+		Title:       meta.Title,
+		Description: meta.Description,
+		Favicon:     meta.Favicon,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 
 	if err := saveLink(projectPath, link); err != nil {
@@ -277,6 +492,9 @@ func runLinkAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("✓ Link '%s' created\n", linkName)
+	if link.Title != "" {
+		fmt.Printf("  %s\n", link.Title)
+	}
 	return nil
 }
 
@@ -292,24 +510,32 @@ func runLinkGet(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Try to find similar links
 		similar, _ := findSimilarLinks(projectPath, identifier, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Link '%s' not found. Did you mean:\n", identifier)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
-		}
-		return fmt.Errorf("link '%s' not found", identifier)
+		return reportNotFound("link", identifier, similar)
 	}
 
+	urls, err := resolveLinkURLs(projectPath, []Link{*link}, linkSetVars, args[1:])
+	if err != nil {
+		return err
+	}
+	link.URL = urls[0]
+
 	if linkCopy {
-		if err := utils.CopyToClipboard(link.URL); err != nil {
+		if err := copyToClipboard(projectPath, link.URL); err != nil {
 			return err
 		}
-		
-	} 
-		
+	}
+
+	if isStructuredOutput() {
+		return printOutput(newLinkOutput(*link))
+	}
+
 	fmt.Printf("URL: %s | (%s)\n", link.URL, strings.Join(link.Keywords, ", "))
+	if link.Title != "" {
+		fmt.Printf("Title: %s\n", link.Title)
+	}
+	if link.Description != "" {
+		fmt.Printf("Description: %s\n", link.Description)
+	}
 	
 	return nil
 }
@@ -326,19 +552,13 @@ func runLinkEdit(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Try to find similar links
 		similar, _ := findSimilarLinks(projectPath, identifier, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Link '%s' not found. Did you mean:\n", identifier)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
-		}
-		return fmt.Errorf("link '%s' not found", identifier)
+		return reportNotFound("link", identifier, similar)
 	}
 
 	// Update URL if provided
-	if linkURL != "" {
+	if linkURL != "" && linkURL != link.URL {
 		link.URL = linkURL
+		link.Check = nil
 	}
 
 	// Add keywords if provided
@@ -369,6 +589,7 @@ func runLinkEdit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	link.UpdatedAt = time.Now()
 	if err := saveLink(projectPath, link); err != nil {
 		return err
 	}
@@ -389,14 +610,7 @@ func runLinkRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Try to find similar links
 		similar, _ := findSimilarLinks(projectPath, identifier, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Link '%s' not found. Did you mean:\n", identifier)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
-		}
-		return fmt.Errorf("link '%s' not found", identifier)
+		return reportNotFound("link", identifier, similar)
 	}
 
 	if !utils.AskConfirmation(fmt.Sprintf("Are you sure you want to delete link '%s'?", link.Name)) {
@@ -413,6 +627,85 @@ func runLinkRemove(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runLinkOpen(cmd *cobra.Command, args []string) error {
+	if linkOpenKeyword != "" && !linkOpenAll {
+		return fmt.Errorf("--keyword can only be used with --all")
+	}
+
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	var links []Link
+	var values []string
+	switch {
+	case len(args) >= 1:
+		values = args[1:]
+		identifier := strings.TrimPrefix(args[0], "#")
+		links, err = findLinksByNameOrKeyword(projectPath, identifier)
+		if err != nil {
+			// Try to find similar links
+			similar, _ := findSimilarLinks(projectPath, identifier, 3)
+			return reportNotFound("link", identifier, similar)
+		}
+	case linkOpenAll:
+		all, err := listLinks(projectPath)
+		if err != nil {
+			return err
+		}
+		for _, link := range all {
+			if linkOpenKeyword == "" || containsFold(link.Keywords, linkOpenKeyword) {
+				links = append(links, link)
+			}
+		}
+		if len(links) == 0 {
+			return fmt.Errorf("no links to open")
+		}
+	default:
+		return fmt.Errorf("specify a link or --all")
+	}
+
+	urls, err := resolveLinkURLs(projectPath, links, linkSetVars, values)
+	if err != nil {
+		return err
+	}
+
+	if !linkOpenPrint && !utils.HasDisplay() {
+		fmt.Fprintln(os.Stderr, "No display available, printing the links instead")
+		linkOpenPrint = true
+	}
+
+	if linkOpenPrint {
+		for _, url := range urls {
+			fmt.Println(url)
+		}
+		return nil
+	}
+
+	if err := utils.OpenURLs(effectiveConfig(projectPath).Browser, urls); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	for i, link := range links {
+		fmt.Printf("✓ Opened '%s' (%s)\n", link.Name, urls[i])
+	}
+	return nil
+}
+
+// matches reports whether the name, URL, keywords, title or description of
+// the link contain text, ignoring case
+func (l Link) matches(text string) bool {
+	text = strings.ToLower(text)
+	fields := append([]string{l.Name, l.URL, l.Title, l.Description}, l.Keywords...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -421,3 +714,12 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+func containsFold(slice []string, item string) bool {
+	for _, s := range slice {
+		if strings.EqualFold(s, item) {
+			return true
+		}
+	}
+	return false
+}
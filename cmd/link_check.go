@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// LinkCheck is the result of the last health check of a link
+type LinkCheck struct {
+	Status    int       `json:"status"`
+	FinalURL  string    `json:"final_url,omitempty"`
+	Redirects []int     `json:"redirects,omitempty"` // status of each redirect
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Broken reports whether the link could not be reached
+func (c *LinkCheck) Broken() bool {
+	return c.Error != "" || c.Status >= 400
+}
+
+// Redirected reports whether the link ends up on another URL
+func (c *LinkCheck) Redirected(url string) bool {
+	return c.FinalURL != "" && c.FinalURL != url
+}
+
+// Permanent reports whether every redirect was permanent (301 or 308). Only
+// those may replace the URL: temporary ones typically lead to a login page.
+func (c *LinkCheck) Permanent() bool {
+	for _, status := range c.Redirects {
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			return false
+		}
+	}
+	return len(c.Redirects) > 0
+}
+
+type linkCheckOutput struct {
+	Project   string    `json:"project"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Result    string    `json:"result"`
+	Status    int       `json:"status"`
+	FinalURL  string    `json:"final_url"`
+	Permanent bool      `json:"permanent"`
+	Error     string    `json:"error"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var (
+	linkCheckAllProjects  bool
+	linkCheckFixRedirects bool
+	linkCheckWorkers      int
+	linkCheckTimeout      time.Duration
+)
+
+var linkCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that links are still reachable",
+	Long: `Check every link of the project (or of all projects and clients) with
+concurrent HEAD/GET requests, following redirects. The status, final URL and check date
+are saved on each link, then broken and redirected links are reported.
+--fix-redirects only replaces the URLs whose redirects are all permanent
+(301, 308): temporary ones often lead to a login page.
+
+Example: al link check
+         al link check --all-projects --fix-redirects`,
+	Args: cobra.NoArgs,
+	RunE: runLinkCheck,
+}
+
+func init() {
+	linkCheckCmd.Flags().BoolVar(&linkCheckAllProjects, "all-projects", false, "Check the links of every project and client")
+	linkCheckCmd.Flags().BoolVar(&linkCheckFixRedirects, "fix-redirects", false, "Replace redirected URLs by their final location")
+	linkCheckCmd.Flags().IntVarP(&linkCheckWorkers, "jobs", "j", 8, "Number of concurrent requests")
+	linkCheckCmd.Flags().DurationVar(&linkCheckTimeout, "timeout", 10*time.Second, "Timeout of each request")
+
+	linkCmd.AddCommand(linkCheckCmd)
+	markAllProjectsExclusive(linkCheckCmd)
+}
+
+// linkChecker issues the health check requests with a bounded worker pool
+type linkChecker struct {
+	client  *http.Client
+	workers int
+	now     func() time.Time
+}
+
+func newLinkChecker(client *http.Client, workers int) *linkChecker {
+	if workers < 1 {
+		workers = 1
+	}
+	return &linkChecker{
+		client:  client,
+		workers: workers,
+		now:     time.Now,
+	}
+}
+
+// maxRedirects is the number of redirects followed, as by net/http
+const maxRedirects = 10
+
+// check requests a URL with HEAD, falling back to GET for servers that do
+// not support HEAD
+func (c *linkChecker) check(url string) LinkCheck {
+	result := LinkCheck{CheckedAt: c.now()}
+
+	// Record the status of each redirect on a copy of the shared client
+	client := *c.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		result.Redirects = append(result.Redirects, req.Response.StatusCode)
+		return nil
+	}
+
+	resp, err := client.Head(url)
+	if err != nil || resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		result.Redirects = nil
+		resp, err = client.Get(url)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	result.Status = resp.StatusCode
+	result.FinalURL = resp.Request.URL.String()
+	return result
+}
+
+// checkAll checks the URLs concurrently and returns the results in order
+func (c *linkChecker) checkAll(urls []string) []LinkCheck {
+	results := make([]LinkCheck, len(urls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers && w < len(urls); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.check(urls[i])
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func runLinkCheck(cmd *cobra.Command, args []string) error {
+	projects, err := getNamedProjects(linkCheckAllProjects, getLinkProjectPath)
+	if err != nil {
+		return err
+	}
+
+	type checkedLink struct {
+		project namedProject
+		link    Link
+		url     string
+	}
+
+	var checked []checkedLink
+	var urls []string
+	skipped := 0
+	for _, project := range projects {
+		links, err := listLinks(project.path)
+		if err != nil {
+			return err
+		}
+		defaults, err := loadLinkVars(project.path)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			// URL templates are checked with the default values of their
+			// variables, and skipped when some have none
+			url, err := expandLinkURL(link, defaults, nil, nil)
+			if err != nil {
+				skipped++
+				continue
+			}
+			checked = append(checked, checkedLink{project: project, link: link, url: url})
+			urls = append(urls, url)
+		}
+	}
+
+	if len(checked) == 0 {
+		if isStructuredOutput() {
+			return printOutput([]linkCheckOutput{})
+		}
+		fmt.Println("No links found.")
+		return nil
+	}
+
+	checker := newLinkChecker(&http.Client{Timeout: linkCheckTimeout}, linkCheckWorkers)
+	results := checker.checkAll(urls)
+
+	var report []linkCheckOutput
+	broken, redirected, fixed := 0, 0, 0
+	for i := range checked {
+		link := checked[i].link
+		result := results[i]
+
+		out := linkCheckOutput{
+			Project:   checked[i].project.name,
+			Name:      link.Name,
+			URL:       checked[i].url,
+			Result:    "ok",
+			Status:    result.Status,
+			FinalURL:  result.FinalURL,
+			Permanent: result.Permanent(),
+			Error:     result.Error,
+			CheckedAt: result.CheckedAt,
+		}
+
+		switch {
+		case result.Broken():
+			out.Result = "broken"
+			broken++
+		case result.Redirected(checked[i].url):
+			out.Result = "redirected"
+			redirected++
+			if linkCheckFixRedirects && result.Permanent() && !isLinkTemplate(link.URL) {
+				link.URL = result.FinalURL
+				link.UpdatedAt = result.CheckedAt
+				out.Result = "fixed"
+				fixed++
+			}
+		}
+
+		link.Check = &result
+		if err := saveLink(checked[i].project.path, &link); err != nil {
+			return err
+		}
+		report = append(report, out)
+	}
+
+	if isStructuredOutput() {
+		return printOutput(report)
+	}
+
+	if broken+redirected > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		if linkCheckAllProjects {
+			fmt.Fprintln(w, "Project\tName\tResult\tStatus\tURL")
+			fmt.Fprintln(w, "-------\t----\t------\t------\t---")
+		} else {
+			fmt.Fprintln(w, "Name\tResult\tStatus\tURL")
+			fmt.Fprintln(w, "----\t------\t------\t---")
+		}
+
+		for _, r := range report {
+			if r.Result == "ok" {
+				continue
+			}
+			status := fmt.Sprint(r.Status)
+			target := r.URL
+			if r.Error != "" {
+				status = "-"
+				target += " (" + r.Error + ")"
+			} else if r.Result != "broken" {
+				target += " -> " + r.FinalURL
+				if !r.Permanent {
+					target += " (temporary)"
+				}
+			}
+			if linkCheckAllProjects {
+				fmt.Fprintf(w, "%s\t", r.Project)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Result, status, target)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	fmt.Printf("✓ %d links checked: %d broken, %d redirected", len(report), broken, redirected)
+	if fixed > 0 {
+		fmt.Printf(" (%d fixed)", fixed)
+	}
+	if skipped > 0 {
+		fmt.Printf(", %d templates skipped (no default values)", skipped)
+	}
+	fmt.Println()
+
+	return nil
+}
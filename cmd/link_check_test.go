@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCheckServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-308", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/moved-then-login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLinkCheckerCheck(t *testing.T) {
+	server := newCheckServer(t)
+	checker := newLinkChecker(&http.Client{Timeout: 200 * time.Millisecond}, 2)
+
+	tests := []struct {
+		path      string
+		status    int
+		finalPath string
+		broken    bool
+		redirects []int
+		permanent bool
+	}{
+		{path: "/ok", status: 200, finalPath: "/ok"},
+		{path: "/missing", status: 404, finalPath: "/missing", broken: true},
+		{path: "/get-only", status: 200, finalPath: "/get-only"},
+		{path: "/moved", status: 200, finalPath: "/ok", redirects: []int{301}, permanent: true},
+		{path: "/moved-308", status: 200, finalPath: "/ok", redirects: []int{308, 301}, permanent: true},
+		{path: "/login", status: 200, finalPath: "/ok", redirects: []int{302}},
+		{path: "/moved-then-login", status: 200, finalPath: "/ok", redirects: []int{301, 302}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			url := server.URL + tt.path
+			result := checker.check(url)
+
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Status != tt.status {
+				t.Errorf("status = %d, want %d", result.Status, tt.status)
+			}
+			if result.FinalURL != server.URL+tt.finalPath {
+				t.Errorf("final URL = %s, want %s", result.FinalURL, server.URL+tt.finalPath)
+			}
+			if result.Broken() != tt.broken {
+				t.Errorf("broken = %v, want %v", result.Broken(), tt.broken)
+			}
+			if result.Redirected(url) != (len(tt.redirects) > 0) {
+				t.Errorf("redirected = %v, want %v", result.Redirected(url), len(tt.redirects) > 0)
+			}
+			if len(result.Redirects) != len(tt.redirects) {
+				t.Fatalf("redirects = %v, want %v", result.Redirects, tt.redirects)
+			}
+			for i := range tt.redirects {
+				if result.Redirects[i] != tt.redirects[i] {
+					t.Errorf("redirects = %v, want %v", result.Redirects, tt.redirects)
+				}
+			}
+			if result.Permanent() != tt.permanent {
+				t.Errorf("permanent = %v, want %v", result.Permanent(), tt.permanent)
+			}
+		})
+	}
+}
+
+func TestLinkCheckerTimeout(t *testing.T) {
+	server := newCheckServer(t)
+	checker := newLinkChecker(&http.Client{Timeout: 100 * time.Millisecond}, 1)
+
+	result := checker.check(server.URL + "/slow")
+	if result.Error == "" {
+		t.Fatalf("expected a timeout error, got status %d", result.Status)
+	}
+	if !result.Broken() {
+		t.Error("a timed out link should be broken")
+	}
+}
+
+func TestLinkCheckerCheckAllKeepsOrder(t *testing.T) {
+	server := newCheckServer(t)
+	checker := newLinkChecker(&http.Client{Timeout: time.Second}, 3)
+
+	paths := []string{"/missing", "/ok", "/moved", "/login", "/missing"}
+	var urls []string
+	for _, path := range paths {
+		urls = append(urls, server.URL+path)
+	}
+
+	want := []int{404, 200, 200, 200, 404}
+	results := checker.checkAll(urls)
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("result %d (%s): status = %d, want %d", i, paths[i], result.Status, want[i])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	linkExportFormat      string
+	linkExportAllProjects bool
+)
+
+var linkExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export links as bookmarks, Markdown or CSV",
+	Long: `Export the links of the project (or of all projects and clients) on
+stdout.
+
+Formats:
+  html  Netscape bookmark file that browsers can import, one folder per project
+  md    Markdown list, one section per project
+  csv   name,url,keywords,project (keywords separated by |)
+
+Example: al link export > bookmarks.html
+         al link export --format md --all-projects > links.md`,
+	Args: cobra.NoArgs,
+	RunE: runLinkExport,
+}
+
+func init() {
+	linkExportCmd.Flags().StringVarP(&linkExportFormat, "format", "f", "html", "Export format (html, md, csv)")
+	linkExportCmd.Flags().BoolVar(&linkExportAllProjects, "all-projects", false, "Export the links of every project and client")
+
+	linkCmd.AddCommand(linkExportCmd)
+	markAllProjectsExclusive(linkExportCmd)
+}
+
+// exportedProject groups the links of a project in an export
+type exportedProject struct {
+	name  string
+	links []Link
+}
+
+// writeBookmarks writes a Netscape bookmark file with one folder per project
+func writeBookmarks(w io.Writer, projects []exportedProject) error {
+	fmt.Fprintln(w, "<!DOCTYPE NETSCAPE-Bookmark-file-1>")
+	fmt.Fprintln(w, `<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">`)
+	fmt.Fprintln(w, "<TITLE>Bookmarks</TITLE>")
+	fmt.Fprintln(w, "<H1>Bookmarks</H1>")
+	fmt.Fprintln(w, "<DL><p>")
+	for _, project := range projects {
+		fmt.Fprintf(w, "    <DT><H3>%s</H3>\n", html.EscapeString(project.name))
+		fmt.Fprintln(w, "    <DL><p>")
+		for _, link := range project.links {
+			tags := ""
+			if len(link.Keywords) > 0 {
+				tags = fmt.Sprintf(` TAGS="%s"`, html.EscapeString(strings.Join(link.Keywords, ",")))
+			}
+			fmt.Fprintf(w, "        <DT><A HREF=\"%s\"%s>%s</A>\n", html.EscapeString(link.URL), tags, html.EscapeString(link.Name))
+		}
+		fmt.Fprintln(w, "    </DL><p>")
+	}
+	_, err := fmt.Fprintln(w, "</DL><p>")
+	return err
+}
+
+// markdownEscaper escapes the characters that would end a Markdown link text
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+// writeMarkdownLinks writes a Markdown list with one section per project
+func writeMarkdownLinks(w io.Writer, projects []exportedProject) error {
+	for i, project := range projects {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s\n\n", project.name)
+		for _, link := range project.links {
+			url := strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(link.URL)
+			fmt.Fprintf(w, "- [%s](%s)", markdownEscaper.Replace(link.Name), url)
+			if len(link.Keywords) > 0 {
+				fmt.Fprintf(w, " — %s", strings.Join(link.Keywords, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// writeLinkCSV writes the links in the CSV layout read by al link import
+func writeLinkCSV(w io.Writer, projects []exportedProject) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"name", "url", "keywords", "project"})
+	for _, project := range projects {
+		for _, link := range project.links {
+			writer.Write([]string{link.Name, link.URL, strings.Join(link.Keywords, "|"), project.name})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func runLinkExport(cmd *cobra.Command, args []string) error {
+	var write func(io.Writer, []exportedProject) error
+	switch linkExportFormat {
+	case "html":
+		write = writeBookmarks
+	case "md":
+		write = writeMarkdownLinks
+	case "csv":
+		write = writeLinkCSV
+	default:
+		return fmt.Errorf("unknown format '%s' (expected html, md or csv)", linkExportFormat)
+	}
+
+	projects, err := getNamedProjects(linkExportAllProjects, getLinkProjectPath)
+	if err != nil {
+		return err
+	}
+
+	var exported []exportedProject
+	for _, project := range projects {
+		links, err := listLinks(project.path)
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			continue
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			return strings.ToLower(links[i].Name) < strings.ToLower(links[j].Name)
+		})
+		exported = append(exported, exportedProject{name: project.name, links: links})
+	}
+
+	return write(os.Stdout, exported)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exportTestProjects holds links whose fields need escaping in every format
+var exportTestProjects = []exportedProject{
+	{name: "R&D <lab>", links: []Link{
+		{Name: `Search "all" <docs>`, URL: "https://example.com/?q=a&b=\"c\"", Keywords: []string{"docs", "a&b"}},
+		{Name: "wiki [old]", URL: "https://wiki.example.com/Page_(draft) v2"},
+	}},
+	{name: "web", links: []Link{
+		{Name: "api, v2", URL: "https://api.example.com", Keywords: []string{"api", "prod"}},
+	}},
+}
+
+func TestWriteBookmarks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeBookmarks(&buf, exportTestProjects); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<DT><H3>R&amp;D &lt;lab&gt;</H3>",
+		`<A HREF="https://example.com/?q=a&amp;b=&#34;c&#34;" TAGS="docs,a&amp;b">Search &#34;all&#34; &lt;docs&gt;</A>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+
+	// al link import reads the file back, the folders becoming keywords
+	links := parseBookmarks(buf.Bytes())
+	if len(links) != 3 {
+		t.Fatalf("read back %d links: %+v", len(links), links)
+	}
+	first := links[0]
+	if first.Name != `Search "all" <docs>` || first.URL != "https://example.com/?q=a&b=\"c\"" || !reflect.DeepEqual(first.Keywords, []string{"R&D <lab>", "docs", "a&b"}) {
+		t.Errorf("read back %+v", first)
+	}
+}
+
+func TestWriteMarkdownLinks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMarkdownLinks(&buf, exportTestProjects); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `## R&D <lab>
+
+- [Search "all" <docs>](https://example.com/?q=a&b="c") — docs, a&b
+- [wiki \[old\]](https://wiki.example.com/Page_%28draft%29%20v2)
+
+## web
+
+- [api, v2](https://api.example.com) — api, prod
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	var escaped bytes.Buffer
+	writeMarkdownLinks(&escaped, []exportedProject{{name: "p", links: []Link{{Name: `a\]b`, URL: "u"}}}})
+	if !strings.Contains(escaped.String(), `- [a\\\]b](u)`) {
+		t.Errorf("backslash not escaped: %s", escaped.String())
+	}
+}
+
+func TestWriteLinkCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLinkCSV(&buf, exportTestProjects); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,url,keywords,project\n" +
+		"\"Search \"\"all\"\" <docs>\",\"https://example.com/?q=a&b=\"\"c\"\"\",docs|a&b,R&D <lab>\n" +
+		"wiki [old],https://wiki.example.com/Page_(draft) v2,,R&D <lab>\n" +
+		"\"api, v2\",https://api.example.com,api|prod,web\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// al link import reads the file back
+	links, err := parseLinkTable(buf.Bytes(), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 || links[0].Name != `Search "all" <docs>` || links[2].Name != "api, v2" || !reflect.DeepEqual(links[2].Keywords, []string{"api", "prod"}) {
+		t.Errorf("read back %+v", links)
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alex/al/utils"
+)
+
+// pageMetadata is what al link add --fetch extracts from a page
+type pageMetadata struct {
+	Title       string
+	Description string
+	SiteName    string
+	Favicon     string
+}
+
+// pageFetcher downloads a page and extracts its metadata. It is an interface
+// so the network can be replaced, e.g. by a local test server.
+type pageFetcher interface {
+	Fetch(rawURL string) (*pageMetadata, error)
+}
+
+// httpPageFetcher fetches pages over HTTP
+type httpPageFetcher struct {
+	client *http.Client
+}
+
+// linkPageFetcher is used by al link add --fetch
+var linkPageFetcher pageFetcher = newHTTPPageFetcher(10 * time.Second)
+
+// Pages are read up to this size, the head is always near the beginning
+const maxFetchedPageSize = 1024 * 1024
+
+func newHTTPPageFetcher(timeout time.Duration) *httpPageFetcher {
+	return &httpPageFetcher{client: &http.Client{Timeout: timeout}}
+}
+
+func (f *httpPageFetcher) Fetch(rawURL string) (*pageMetadata, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "al (+https://github.com/alex/al)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchedPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePageMetadata(resp.Request.URL, data), nil
+}
+
+var (
+	pageTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	pageMeta      = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	pageIcon      = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	pageAttribute = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// tagAttributes returns the attributes of an HTML tag, with lowercase names
+func tagAttributes(tag []byte) map[string]string {
+	attributes := make(map[string]string)
+	for _, m := range pageAttribute.FindAllSubmatch(tag, -1) {
+		value := string(m[2])
+		if len(m[3]) > 0 {
+			value = string(m[3])
+		}
+		attributes[strings.ToLower(string(m[1]))] = html.UnescapeString(value)
+	}
+	return attributes
+}
+
+// cleanText collapses the whitespace of a title or description
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// parsePageMetadata extracts the title, description, site name and favicon
+// of a page. Open Graph values take precedence over <title> and the
+// description meta tag.
+func parsePageMetadata(base *url.URL, data []byte) *pageMetadata {
+	meta := &pageMetadata{}
+
+	if m := pageTitle.FindSubmatch(data); m != nil {
+		meta.Title = cleanText(string(m[1]))
+	}
+
+	for _, tag := range pageMeta.FindAll(data, -1) {
+		attributes := tagAttributes(tag)
+		content := cleanText(attributes["content"])
+		if content == "" {
+			continue
+		}
+		// Some pages set both attributes, each may name the value
+		for _, key := range []string{attributes["property"], attributes["name"]} {
+			switch strings.ToLower(key) {
+			case "og:title":
+				meta.Title = content
+			case "og:description":
+				meta.Description = content
+			case "og:site_name":
+				meta.SiteName = content
+			case "description":
+				if meta.Description == "" {
+					meta.Description = content
+				}
+			}
+		}
+	}
+
+	for _, tag := range pageIcon.FindAll(data, -1) {
+		attributes := tagAttributes(tag)
+		rel := strings.Fields(strings.ToLower(attributes["rel"]))
+		if !contains(rel, "icon") || attributes["href"] == "" {
+			continue
+		}
+		if icon, err := base.Parse(attributes["href"]); err == nil {
+			meta.Favicon = icon.String()
+			break
+		}
+	}
+	if meta.Favicon == "" {
+		meta.Favicon = (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+	}
+
+	return meta
+}
+
+// suggestLinkName builds a link name from the page metadata, or from the URL
+func suggestLinkName(meta *pageMetadata, rawURL string) string {
+	for _, candidate := range []string{meta.Title, meta.SiteName} {
+		name := []rune(utils.Slugify(candidate))
+		if len(name) > 40 {
+			name = name[:40]
+		}
+		if slug := strings.Trim(string(name), "-"); slug != "" {
+			return slug
+		}
+	}
+	return linkNameFromURL(rawURL)
+}
+
+// uniqueLinkName appends -2, -3... to name until no link uses it
+func uniqueLinkName(projectPath, name string) string {
+	if _, err := loadLinkFile(projectPath, name); err != nil {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, err := loadLinkFile(projectPath, candidate); err != nil {
+			return candidate
+		}
+	}
+}
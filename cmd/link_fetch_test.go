@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParsePageMetadata(t *testing.T) {
+	base, _ := url.Parse("https://docs.example.com/guide/start")
+
+	tests := []struct {
+		name string
+		page string
+		want pageMetadata
+	}{
+		{
+			name: "title and description",
+			page: `<html><head><title>
+				Getting   started &amp; more
+			</title><meta name="description" content="How to start"></head></html>`,
+			want: pageMetadata{Title: "Getting started & more", Description: "How to start", Favicon: "https://docs.example.com/favicon.ico"},
+		},
+		{
+			name: "open graph wins",
+			page: `<title>Page</title>
+				<meta name="description" content="Plain description">
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content='OG description'>
+				<meta property="og:site_name" content="Example Docs">`,
+			want: pageMetadata{Title: "OG title", Description: "OG description", SiteName: "Example Docs", Favicon: "https://docs.example.com/favicon.ico"},
+		},
+		{
+			name: "property and name on the same tag",
+			page: `<title>Page</title>
+				<meta property="og:title" name="twitter:title" content="Shared title">
+				<meta name="description" property="og:description" content="Shared description">`,
+			want: pageMetadata{Title: "Shared title", Description: "Shared description", Favicon: "https://docs.example.com/favicon.ico"},
+		},
+		{
+			name: "relative favicon",
+			page: `<link rel="stylesheet" href="/style.css"><link rel="shortcut icon" href="img/icon.png">`,
+			want: pageMetadata{Favicon: "https://docs.example.com/guide/img/icon.png"},
+		},
+		{
+			name: "absolute favicon",
+			page: `<LINK REL="icon" HREF="https://cdn.example.com/favicon.svg">`,
+			want: pageMetadata{Favicon: "https://cdn.example.com/favicon.svg"},
+		},
+		{
+			name: "empty page",
+			page: ``,
+			want: pageMetadata{Favicon: "https://docs.example.com/favicon.ico"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePageMetadata(base, []byte(tt.page))
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// fakePageFetcher returns canned metadata instead of using the network
+type fakePageFetcher map[string]*pageMetadata
+
+func (f fakePageFetcher) Fetch(rawURL string) (*pageMetadata, error) {
+	if meta, ok := f[rawURL]; ok {
+		return meta, nil
+	}
+	return nil, errors.New("unreachable")
+}
+
+func TestLinkAddFetch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	previous := linkPageFetcher
+	t.Cleanup(func() {
+		linkPageFetcher = previous
+		linkURL, linkFetch, linkGlobal = "", false, false
+	})
+	linkPageFetcher = fakePageFetcher{
+		"https://grafana.example.com/d/1": {Title: "Grafana: Production Overview", Description: "Dashboards", Favicon: "https://grafana.example.com/favicon.ico"},
+		"https://example.com/site":        {SiteName: "Example Site"},
+	}
+	linkFetch, linkGlobal = true, true
+
+	tests := []struct {
+		url         string
+		wantName    string
+		title       string
+		description string
+		favicon     string
+	}{
+		{url: "https://grafana.example.com/d/1", wantName: "grafana-production-overview", title: "Grafana: Production Overview", description: "Dashboards", favicon: "https://grafana.example.com/favicon.ico"},
+		{url: "https://grafana.example.com/d/1", wantName: "grafana-production-overview-2", title: "Grafana: Production Overview", description: "Dashboards", favicon: "https://grafana.example.com/favicon.ico"},
+		{url: "https://example.com/site", wantName: "example-site"},
+		{url: "https://down.example.com/status", wantName: "down-example-com-status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			linkURL = tt.url
+			if err := runLinkAdd(linkAddCmd, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			link, err := loadLink("", tt.wantName)
+			if err != nil {
+				t.Fatalf("link %s not created: %v", tt.wantName, err)
+			}
+			if link.URL != tt.url || link.Title != tt.title || link.Description != tt.description || link.Favicon != tt.favicon {
+				t.Errorf("got %+v", link)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+type linkImportOutput struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Keywords []string `json:"keywords"`
+	Result   string   `json:"result"`
+}
+
+var (
+	linkImportFormat     string
+	linkImportOnConflict string
+)
+
+var linkImportCmd = &cobra.Command{
+	Use:   "import [file|-]",
+	Short: "Import links from a bookmarks export, a CSV/TSV or a list of URLs",
+	Long: `Import links from a file (or stdin with -).
+
+Formats:
+  html  Netscape bookmark file exported by Chrome or Firefox,
+        bookmark folders become keywords
+  csv   name,url,keywords,title (keywords separated by |), or the columns
+        named by a header line, such as the ones of al link export
+  tsv   same columns separated by tabs
+  list  one URL per line
+
+Links whose URL already exists are skipped. When a name is already used by
+another link, --on-conflict decides: skip, overwrite or rename.
+
+Example: al link import bookmarks.html
+         al link import links.csv --on-conflict rename
+         cat urls.txt | al link import - --format list`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLinkImport,
+}
+
+func init() {
+	linkImportCmd.Flags().StringVarP(&linkImportFormat, "format", "f", "auto", "Input format (auto, html, csv, tsv, list)")
+	linkImportCmd.Flags().StringVar(&linkImportOnConflict, "on-conflict", "skip", "Name conflict policy (skip, overwrite, rename)")
+
+	linkCmd.AddCommand(linkImportCmd)
+}
+
+// detectImportFormat guesses the format from the file extension, then from the content
+func detectImportFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return "html"
+	case ".csv":
+		return "csv"
+	case ".tsv":
+		return "tsv"
+	case ".txt":
+		return "list"
+	}
+
+	head := strings.ToLower(string(data[:min(len(data), 1024)]))
+	firstLine := strings.SplitN(head, "\n", 2)[0]
+	switch {
+	case strings.Contains(head, "netscape-bookmark") || strings.Contains(head, "<a href"):
+		return "html"
+	case strings.Contains(firstLine, "\t"):
+		return "tsv"
+	case strings.Contains(firstLine, ","):
+		return "csv"
+	}
+	return "list"
+}
+
+var (
+	bookmarkToken = regexp.MustCompile(`(?is)<h3([^>]*)>(.*?)</h3>|<a\s([^>]*)>(.*?)</a>|<dl[^>]*>|</dl>`)
+	bookmarkHref  = regexp.MustCompile(`(?is)\bhref\s*=\s*"([^"]*)"`)
+	bookmarkTags  = regexp.MustCompile(`(?is)\btags\s*=\s*"([^"]*)"`)
+	htmlTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// parseBookmarks reads a Netscape bookmark file. The folders containing a
+// bookmark (and Firefox tags) become its keywords, except the toolbar folder.
+func parseBookmarks(data []byte) []Link {
+	var links []Link
+	var folders []string
+	pendingFolder := ""
+
+	for _, m := range bookmarkToken.FindAllSubmatch(data, -1) {
+		token := strings.ToLower(string(m[0]))
+		switch {
+		case strings.HasPrefix(token, "<h3"):
+			pendingFolder = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(string(m[2]), "")))
+			if bytes.Contains(bytes.ToLower(m[1]), []byte("personal_toolbar_folder")) {
+				pendingFolder = ""
+			}
+		case strings.HasPrefix(token, "<dl"):
+			folders = append(folders, pendingFolder)
+			pendingFolder = ""
+		case strings.HasPrefix(token, "</dl"):
+			if len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+			}
+		case strings.HasPrefix(token, "<a"):
+			href := bookmarkHref.FindSubmatch(m[3])
+			if href == nil {
+				continue
+			}
+
+			var keywords []string
+			for _, folder := range folders {
+				if folder != "" && !contains(keywords, folder) {
+					keywords = append(keywords, folder)
+				}
+			}
+			if tags := bookmarkTags.FindSubmatch(m[3]); tags != nil {
+				for _, tag := range strings.Split(html.UnescapeString(string(tags[1])), ",") {
+					tag = strings.TrimSpace(tag)
+					if tag != "" && !contains(keywords, tag) {
+						keywords = append(keywords, tag)
+					}
+				}
+			}
+
+			title := strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(string(m[4]), "")))
+			links = append(links, Link{
+				Name:     title,
+				Title:    title,
+				URL:      strings.TrimSpace(html.UnescapeString(string(href[1]))),
+				Keywords: keywords,
+			})
+		}
+	}
+
+	return links
+}
+
+// linkTableColumns is the column order of a table without header line
+var linkTableColumns = []string{"name", "url", "keywords", "title"}
+
+// parseLinkTable reads name,url,keywords,title records. A header line naming
+// a url column gives the order of the columns instead, other columns are ignored.
+func parseLinkTable(data []byte, comma rune) ([]Link, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse links: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, column := range linkTableColumns {
+		columns[column] = i
+	}
+	if len(records) > 0 && contains(normalizeHeader(records[0]), "url") {
+		columns = make(map[string]int)
+		for i, column := range normalizeHeader(records[0]) {
+			if _, ok := columns[column]; !ok {
+				columns[column] = i
+			}
+		}
+		records = records[1:]
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var links []Link
+	for _, record := range records {
+		link := Link{
+			Name:  field(record, "name"),
+			URL:   field(record, "url"),
+			Title: field(record, "title"),
+		}
+		if link.URL == "" {
+			continue
+		}
+		for _, keyword := range strings.Split(field(record, "keywords"), "|") {
+			keyword = strings.TrimSpace(keyword)
+			if keyword != "" {
+				link.Keywords = append(link.Keywords, keyword)
+			}
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// normalizeHeader lowercases the column names of a header line
+func normalizeHeader(record []string) []string {
+	header := make([]string, len(record))
+	for i, column := range record {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+	return header
+}
+
+// parseURLList reads one URL per line, ignoring blank lines and # comments
+func parseURLList(data []byte) []Link {
+	var links []Link
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		links = append(links, Link{URL: line})
+	}
+	return links
+}
+
+// defaultLinkName is used for URLs without host nor path, such as file:///
+const defaultLinkName = "link"
+
+// linkNameFromURL builds a link name from the host and path of a URL
+func linkNameFromURL(rawURL string) string {
+	var name string
+	if u, err := url.Parse(rawURL); err != nil {
+		name = utils.Slugify(rawURL)
+	} else {
+		host := strings.TrimPrefix(u.Hostname(), "www.")
+		name = utils.Slugify(host + " " + u.Path)
+	}
+	if name == "" {
+		return defaultLinkName
+	}
+	return name
+}
+
+func isImportableURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Scheme == "file")
+}
+
+// normalizeLinkURL is used to detect links already present
+func normalizeLinkURL(rawURL string) string {
+	return strings.TrimSuffix(strings.TrimSpace(rawURL), "/")
+}
+
+func runLinkImport(cmd *cobra.Command, args []string) error {
+	switch linkImportOnConflict {
+	case "skip", "overwrite", "rename":
+	default:
+		return fmt.Errorf("unknown conflict policy '%s' (expected skip, overwrite or rename)", linkImportOnConflict)
+	}
+
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	format := linkImportFormat
+	if format == "auto" {
+		format = detectImportFormat(args[0], data)
+	}
+
+	var imported []Link
+	switch format {
+	case "html":
+		imported = parseBookmarks(data)
+	case "csv":
+		imported, err = parseLinkTable(data, ',')
+	case "tsv":
+		imported, err = parseLinkTable(data, '\t')
+	case "list":
+		imported = parseURLList(data)
+	default:
+		return fmt.Errorf("unknown format '%s' (expected html, csv, tsv or list)", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	existing, err := listLinks(projectPath)
+	if err != nil {
+		return err
+	}
+
+	knownURLs := make(map[string]bool)
+	for _, link := range existing {
+		knownURLs[normalizeLinkURL(link.URL)] = true
+	}
+
+	var report []linkImportOutput
+	counts := make(map[string]int)
+	for _, link := range imported {
+		name := utils.Slugify(link.Name)
+		if name == "" {
+			// A generated name never conflicts: link, link-2...
+			name = linkNameFromURL(link.URL)
+			if name == defaultLinkName {
+				name = uniqueLinkName(projectPath, name)
+			}
+		}
+		link.Name = name
+		link.CreatedAt = time.Now()
+		link.UpdatedAt = link.CreatedAt
+
+		result := "imported"
+		switch {
+		case !isImportableURL(link.URL):
+			result = "invalid"
+		case knownURLs[normalizeLinkURL(link.URL)]:
+			result = "duplicate"
+		default:
+			if _, err := loadLinkFile(projectPath, link.Name); err == nil {
+				switch linkImportOnConflict {
+				case "skip":
+					result = "conflict"
+				case "overwrite":
+					result = "overwritten"
+				case "rename":
+					link.Name = uniqueLinkName(projectPath, link.Name)
+					result = "renamed"
+				}
+			}
+		}
+
+		if result == "imported" || result == "overwritten" || result == "renamed" {
+			if err := saveLink(projectPath, &link); err != nil {
+				return err
+			}
+			knownURLs[normalizeLinkURL(link.URL)] = true
+		}
+
+		keywords := link.Keywords
+		if keywords == nil {
+			keywords = []string{}
+		}
+		report = append(report, linkImportOutput{Name: link.Name, URL: link.URL, Keywords: keywords, Result: result})
+		counts[result]++
+	}
+
+	if isStructuredOutput() {
+		if report == nil {
+			report = []linkImportOutput{}
+		}
+		return printOutput(report)
+	}
+
+	if counts["conflict"]+counts["overwritten"]+counts["renamed"]+counts["invalid"] > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "Name\tResult\tURL")
+		fmt.Fprintln(w, "----\t------\t---")
+		for _, r := range report {
+			if r.Result == "imported" || r.Result == "duplicate" {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Result, r.URL)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	fmt.Printf("✓ %d links imported (%s): %d duplicates skipped",
+		counts["imported"]+counts["overwritten"]+counts["renamed"], format, counts["duplicate"])
+	if n := counts["conflict"] + counts["overwritten"] + counts["renamed"]; n > 0 {
+		fmt.Printf(", %d name conflicts (%s)", n, linkImportOnConflict)
+	}
+	if counts["invalid"] > 0 {
+		fmt.Printf(", %d invalid URLs", counts["invalid"])
+	}
+	fmt.Println()
+
+	return nil
+}
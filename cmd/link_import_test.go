@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+		want string
+	}{
+		{path: "bookmarks.HTML", want: "html"},
+		{path: "links.csv", want: "csv"},
+		{path: "links.tsv", want: "tsv"},
+		{path: "urls.txt", want: "list"},
+		{path: "-", data: "<!DOCTYPE NETSCAPE-Bookmark-file-1>", want: "html"},
+		{path: "-", data: "name\turl\nx\thttps://x", want: "tsv"},
+		{path: "-", data: "name,url\nx,https://x", want: "csv"},
+		{path: "-", data: "https://a.example.com\nhttps://b.example.com", want: "list"},
+	}
+
+	for _, tt := range tests {
+		if got := detectImportFormat(tt.path, []byte(tt.data)); got != tt.want {
+			t.Errorf("detectImportFormat(%q, %q) = %s, want %s", tt.path, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseBookmarks(t *testing.T) {
+	data := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+  <DT><H3 PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+  <DL><p>
+    <DT><A HREF="https://grafana.example.com/d/1" ADD_DATE="1">Grafana &amp; <b>Prod</b></A>
+    <DT><H3>Work</H3>
+    <DL><p>
+      <DT><A HREF="https://jira.example.com" TAGS="tickets,Work">Jira</A>
+    </DL><p>
+  </DL><p>
+  <DT><A HREF="https://example.com/?a=1&amp;b=2">Example</A>
+  <DT><A NAME="no-href">Broken</A>
+</DL>`
+
+	want := []Link{
+		{Name: "Grafana & Prod", Title: "Grafana & Prod", URL: "https://grafana.example.com/d/1"},
+		{Name: "Jira", Title: "Jira", URL: "https://jira.example.com", Keywords: []string{"Work", "tickets"}},
+		{Name: "Example", Title: "Example", URL: "https://example.com/?a=1&b=2"},
+	}
+	if got := parseBookmarks([]byte(data)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseLinkTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		comma rune
+		want  []Link
+	}{
+		{
+			name:  "csv without header",
+			data:  "grafana,https://grafana.example.com,monitoring|prod,Grafana\n\"a, b\",https://ab.example.com\nonly-a-name\n",
+			comma: ',',
+			want: []Link{
+				{Name: "grafana", URL: "https://grafana.example.com", Keywords: []string{"monitoring", "prod"}, Title: "Grafana"},
+				{Name: "a, b", URL: "https://ab.example.com"},
+			},
+		},
+		{
+			name:  "header of al link export",
+			data:  "name,url,keywords,project\njira,https://jira.example.com,tickets,web\n",
+			comma: ',',
+			want:  []Link{{Name: "jira", URL: "https://jira.example.com", Keywords: []string{"tickets"}}},
+		},
+		{
+			name:  "header in another order",
+			data:  "Title\tURL\tName\nThe Wiki\thttps://wiki.example.com\twiki\n",
+			comma: '\t',
+			want:  []Link{{Name: "wiki", URL: "https://wiki.example.com", Title: "The Wiki"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLinkTable([]byte(tt.data), tt.comma)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURLList(t *testing.T) {
+	got := parseURLList([]byte("# team links\nhttps://a.example.com\n\n  https://b.example.com  \n"))
+	want := []Link{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLinkNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://www.example.com/docs/start": "example-com-docs-start",
+		"https://grafana.example.com":        "grafana-example-com",
+		"file:///":                           defaultLinkName,
+	}
+	for in, want := range tests {
+		if got := linkNameFromURL(in); got != want {
+			t.Errorf("linkNameFromURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// runTestImport imports data with the conflict policy and returns the report
+func runTestImport(t *testing.T, data, policy string) []linkImportOutput {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "links.csv")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	linkImportFormat, linkImportOnConflict = "auto", policy
+	out := captureStdout(t, func() {
+		if err := runLinkImport(linkImportCmd, []string{path}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var report []linkImportOutput
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	return report
+}
+
+func TestRunLinkImport(t *testing.T) {
+	setupTestHome(t)
+	t.Cleanup(func() {
+		linkGlobal, outputFormat = false, ""
+		linkImportFormat, linkImportOnConflict = "auto", "skip"
+	})
+	linkGlobal, outputFormat = true, "json"
+
+	if err := saveLink("", &Link{Name: "grafana", URL: "https://grafana.example.com/old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results := func(report []linkImportOutput) map[string]string {
+		got := make(map[string]string)
+		for _, r := range report {
+			got[r.URL] = r.Name + " " + r.Result
+		}
+		return got
+	}
+
+	report := runTestImport(t, "grafana,https://grafana.example.com/new,,Grafana\njira,https://jira.example.com/\nbad,not a url\n", "skip")
+	want := map[string]string{
+		"https://grafana.example.com/new": "grafana conflict",
+		"https://jira.example.com/":       "jira imported",
+		"not a url":                       "bad invalid",
+	}
+	if got := results(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("skip: got %v, want %v", got, want)
+	}
+
+	report = runTestImport(t, "grafana,https://grafana.example.com/new,,Grafana\njira,https://jira.example.com\n", "rename")
+	want = map[string]string{
+		"https://grafana.example.com/new": "grafana-2 renamed",
+		"https://jira.example.com":        "jira duplicate",
+	}
+	if got := results(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("rename: got %v, want %v", got, want)
+	}
+	if link, err := loadLink("", "grafana-2"); err != nil || link.Title != "Grafana" {
+		t.Errorf("renamed link: %+v, %v", link, err)
+	}
+
+	report = runTestImport(t, "grafana,https://grafana.example.com/v3,dashboards,Grafana v3\n", "overwrite")
+	if got := results(report); got["https://grafana.example.com/v3"] != "grafana overwritten" {
+		t.Errorf("overwrite: got %v", got)
+	}
+	link, err := loadLink("", "grafana")
+	if err != nil || link.URL != "https://grafana.example.com/v3" || link.Title != "Grafana v3" || !reflect.DeepEqual(link.Keywords, []string{"dashboards"}) {
+		t.Errorf("overwritten link: %+v, %v", link, err)
+	}
+
+	linkImportOnConflict = "merge"
+	if err := runLinkImport(linkImportCmd, []string{"-"}); err == nil {
+		t.Error("expected an error for an unknown conflict policy")
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex/al/storage"
+)
+
+// setupOpenLinks registers the project web with a few links and returns its path
+func setupOpenLinks(t *testing.T) string {
+	t.Helper()
+	setupTestHome(t)
+	project := t.TempDir()
+	if err := storage.SaveProjects(map[string]storage.Project{"web": {Path: project, Shortcuts: []string{"web"}}}); err != nil {
+		t.Fatal(err)
+	}
+	links := []Link{
+		{Name: "grafana", URL: "https://grafana.example.com", Keywords: []string{"monitoring"}},
+		{Name: "sentry", URL: "https://sentry.example.com", Keywords: []string{"Monitoring"}},
+		{Name: "jira", URL: "https://jira.example.com/browse/{{issue}}"},
+	}
+	for i := range links {
+		if err := saveLink(project, &links[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() {
+		linkTarget, linkOpenAll, linkOpenKeyword, linkOpenPrint, linkSetVars = "", false, "", false, nil
+	})
+	linkTarget = "web"
+	return project
+}
+
+func TestRunLinkOpenPrint(t *testing.T) {
+	setupOpenLinks(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		all     bool
+		keyword string
+		want    string
+	}{
+		{name: "by name", args: []string{"#grafana"}, want: "https://grafana.example.com\n"},
+		{name: "keyword opens every match", args: []string{"#MONITORING"}, want: "https://grafana.example.com\nhttps://sentry.example.com\n"},
+		{name: "URL values", args: []string{"#jira", "ABC-12"}, want: "https://jira.example.com/browse/ABC-12\n"},
+		{name: "all with a keyword", all: true, keyword: "monitoring", want: "https://grafana.example.com\nhttps://sentry.example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkOpenAll, linkOpenKeyword, linkOpenPrint = tt.all, tt.keyword, true
+			out := captureStdout(t, func() {
+				if err := runLinkOpen(linkOpenCmd, tt.args); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if out != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLinkOpenErrors(t *testing.T) {
+	setupOpenLinks(t)
+	linkOpenPrint = true
+
+	tests := []struct {
+		name    string
+		args    []string
+		all     bool
+		keyword string
+		wantErr string
+	}{
+		{name: "keyword without --all", args: []string{"#grafana"}, keyword: "monitoring", wantErr: "--keyword can only be used with --all"},
+		{name: "nothing to open", wantErr: "specify a link or --all"},
+		{name: "no link with the keyword", all: true, keyword: "ci", wantErr: "no links to open"},
+		{name: "unknown link", args: []string{"#zzz"}, wantErr: "link 'zzz' not found"},
+		{name: "missing URL value", args: []string{"#jira"}, wantErr: "issue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkOpenAll, linkOpenKeyword = tt.all, tt.keyword
+			err := runLinkOpen(linkOpenCmd, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunLinkOpenBrowser(t *testing.T) {
+	project := setupOpenLinks(t)
+	t.Setenv("DISPLAY", ":0")
+
+	// The browser writes the URLs it is given to a file
+	dir := t.TempDir()
+	opened := filepath.Join(dir, "opened")
+	browser := filepath.Join(dir, "browser")
+	if err := os.WriteFile(browser, []byte("#!/bin/sh\necho \"$@\" > \""+opened+"\"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	setConfig := func(path, value string) {
+		t.Helper()
+		raw, _ := json.Marshal(value)
+		if err := storage.SaveConfigLayer(path, map[string]json.RawMessage{"browser": raw}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	globalConfig, err := storage.GetGlobalConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setConfig(globalConfig, browser+" --new-window")
+	// A project cannot choose the command run by al link open
+	setConfig(storage.GetLocalConfigPath(project), "/bin/false")
+
+	out := captureStdout(t, func() {
+		if err := runLinkOpen(linkOpenCmd, []string{"#monitoring"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "✓ Opened 'grafana' (https://grafana.example.com)") || !strings.Contains(out, "✓ Opened 'sentry'") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	want := "--new-window https://grafana.example.com https://sentry.example.com\n"
+	var got []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if got, err = os.ReadFile(opened); err == nil && len(got) > 0 {
+			break
+		}
+	}
+	if string(got) != want {
+		t.Errorf("browser called with %q, want %q", got, want)
+	}
+}
+
+func TestRunLinkOpenWithoutDisplay(t *testing.T) {
+	setupOpenLinks(t)
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	out := captureStdout(t, func() {
+		if err := runLinkOpen(linkOpenCmd, []string{"#grafana"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "https://grafana.example.com\n" {
+		t.Errorf("got %q, want the URL printed", out)
+	}
+}
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+// linkVarsFileName stores the default values of the link URL variables
+const linkVarsFileName = "link_vars"
+
+// linkPlaceholder matches the {{variable}} placeholders of a link URL
+var linkPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+type linkVarOutput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var linkVarsCmd = &cobra.Command{
+	Use:   "vars [action]",
+	Short: "Manage the default values of link URL variables",
+	Long: `Manage the default values of the {{variables}} used in link URLs.
+Actions: list, set, unset
+
+Example: al link add #grafana -u "https://grafana.acme.io/{{env}}/d/abc"
+         al link vars set env prod
+         al link get #grafana --set env=staging`,
+	Args: cobra.NoArgs,
+	RunE: runLinkVarsList,
+}
+
+var linkVarsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the default values",
+	Args:  cobra.NoArgs,
+	RunE:  runLinkVarsList,
+}
+
+var linkVarsSetCmd = &cobra.Command{
+	Use:   "set [name] [value]",
+	Short: "Set the default value of a variable",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runLinkVarsSet,
+}
+
+var linkVarsUnsetCmd = &cobra.Command{
+	Use:   "unset [name]",
+	Short: "Remove the default value of a variable",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runLinkVarsUnset,
+}
+
+func init() {
+	linkVarsCmd.AddCommand(linkVarsListCmd)
+	linkVarsCmd.AddCommand(linkVarsSetCmd)
+	linkVarsCmd.AddCommand(linkVarsUnsetCmd)
+
+	linkCmd.AddCommand(linkVarsCmd)
+}
+
+func getLinkVarsFilePath(projectPath string) string {
+	return filepath.Join(storage.GetDataDir(projectPath), linkVarsFileName)
+}
+
+func loadLinkVars(projectPath string) (map[string]string, error) {
+	data, err := os.ReadFile(getLinkVarsFilePath(projectPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]string), nil
+		}
+		return nil, err
+	}
+
+	var vars map[string]string
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse link variables: %w", err)
+	}
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+
+	return vars, nil
+}
+
+func saveLinkVars(projectPath string, vars map[string]string) error {
+	if err := ensureDataDir(storage.GetDataDir(projectPath)); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(getLinkVarsFilePath(projectPath), data, 0600)
+}
+
+// linkPlaceholders returns the variables of a URL in order of appearance
+func linkPlaceholders(rawURL string) []string {
+	var names []string
+	for _, m := range linkPlaceholder.FindAllStringSubmatch(rawURL, -1) {
+		if !contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// copyLinkVars copies the default values of the variables of rawURL to
+// another project, so that a moved or copied link still expands. Values
+// already set there are kept.
+func copyLinkVars(sourcePath, destPath, rawURL string) error {
+	names := linkPlaceholders(rawURL)
+	if len(names) == 0 {
+		return nil
+	}
+
+	source, err := loadLinkVars(sourcePath)
+	if err != nil {
+		return err
+	}
+	dest, err := loadLinkVars(destPath)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, name := range names {
+		value, ok := source[name]
+		if !ok {
+			continue
+		}
+		if current, ok := dest[name]; ok {
+			if current != value {
+				fmt.Fprintf(os.Stderr, "Warning: variable '%s' keeps its value '%s' in %s ('%s' in %s)\n",
+					name, current, scopeLabel(destPath), value, scopeLabel(sourcePath))
+			}
+			continue
+		}
+		dest[name] = value
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return saveLinkVars(destPath, dest)
+}
+
+// isLinkTemplate reports whether a URL contains placeholders
+func isLinkTemplate(rawURL string) bool {
+	return linkPlaceholder.MatchString(rawURL)
+}
+
+// parseLinkVarAssignments parses the name=value pairs given with --set
+func parseLinkVarAssignments(assignments []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid variable '%s' (expected name=value)", assignment)
+		}
+		vars[strings.TrimSpace(name)] = value
+	}
+	return vars, nil
+}
+
+// expandLinkURL replaces the placeholders of a link URL. Values come from
+// --set first. The positional values fill the other placeholders in order of
+// appearance when there is one per placeholder, otherwise only those without
+// a project default. Every placeholder must be resolved.
+func expandLinkURL(link Link, defaults, set map[string]string, positional []string) (string, error) {
+	names := linkPlaceholders(link.URL)
+
+	values := make(map[string]string)
+	var free []string
+	for _, name := range names {
+		if value, ok := set[name]; ok {
+			values[name] = value
+		} else {
+			free = append(free, name)
+		}
+	}
+
+	overrideDefaults := len(positional) == len(free)
+	for _, name := range free {
+		if value, ok := defaults[name]; ok && !overrideDefaults {
+			values[name] = value
+			continue
+		}
+		if len(positional) > 0 {
+			values[name] = positional[0]
+			positional = positional[1:]
+		}
+	}
+
+	if len(positional) > 0 {
+		return "", fmt.Errorf("too many values for link '%s' (variables: %s)", link.Name, strings.Join(names, ", "))
+	}
+
+	var missing []string
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("link '%s' (%s) needs a value for: %s (use --set %s=...)",
+			link.Name, link.URL, strings.Join(missing, ", "), missing[0])
+	}
+
+	// Values are escaped for the part of the URL the placeholder is in
+	query := strings.Index(link.URL, "?")
+	fragment := strings.Index(link.URL, "#")
+	var b strings.Builder
+	last := 0
+	for _, m := range linkPlaceholder.FindAllStringSubmatchIndex(link.URL, -1) {
+		b.WriteString(link.URL[last:m[0]])
+		value := values[link.URL[m[2]:m[3]]]
+		if query >= 0 && m[0] > query && (fragment < 0 || m[0] < fragment) {
+			b.WriteString(url.QueryEscape(value))
+		} else {
+			b.WriteString(escapePathValue(value))
+		}
+		last = m[1]
+	}
+	b.WriteString(link.URL[last:])
+	return b.String(), nil
+}
+
+// escapePathValue escapes a value placed in the path of a URL, keeping its
+// slashes so it can span several segments
+func escapePathValue(value string) string {
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// resolveLinkURLs expands the URLs of links with the --set values, the
+// positional values and the defaults of the project
+func resolveLinkURLs(projectPath string, links []Link, assignments, positional []string) ([]string, error) {
+	set, err := parseLinkVarAssignments(assignments)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := loadLinkVars(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(links))
+	for _, link := range links {
+		resolved, err := expandLinkURL(link, defaults, set, positional)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, resolved)
+	}
+	return urls, nil
+}
+
+func runLinkVarsList(cmd *cobra.Command, args []string) error {
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	vars, err := loadLinkVars(projectPath)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if isStructuredOutput() {
+		out := make([]linkVarOutput, 0, len(names))
+		for _, name := range names {
+			out = append(out, linkVarOutput{Name: name, Value: vars[name]})
+		}
+		return printOutput(out)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No link variables found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tValue")
+	fmt.Fprintln(w, "----\t-----")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, vars[name])
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runLinkVarsSet(cmd *cobra.Command, args []string) error {
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	name, value := args[0], args[1]
+	if placeholder := "{{" + name + "}}"; linkPlaceholder.FindString(placeholder) != placeholder {
+		return fmt.Errorf("invalid variable name '%s' (letters, digits, '_', '.' and '-' only)", name)
+	}
+
+	vars, err := loadLinkVars(projectPath)
+	if err != nil {
+		return err
+	}
+	vars[name] = value
+
+	if err := saveLinkVars(projectPath, vars); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Link variable '%s' set\n", name)
+	return nil
+}
+
+func runLinkVarsUnset(cmd *cobra.Command, args []string) error {
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+
+	vars, err := loadLinkVars(projectPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := vars[name]; !ok {
+		return fmt.Errorf("link variable '%s' not found", name)
+	}
+	delete(vars, name)
+
+	if err := saveLinkVars(projectPath, vars); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Link variable '%s' removed\n", name)
+	return nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestExpandLinkURLEscaping(t *testing.T) {
+	tests := []struct {
+		url   string
+		value string
+		want  string
+	}{
+		{url: "https://git.example.com/{{repo}}/issues", value: "team/api", want: "https://git.example.com/team/api/issues"},
+		{url: "https://git.example.com/{{repo}}", value: "a b?c", want: "https://git.example.com/a%20b%3Fc"},
+		{url: "https://search.example.com/?q={{repo}}&page=1", value: "a&b=c+d", want: "https://search.example.com/?q=a%26b%3Dc%2Bd&page=1"},
+		{url: "https://search.example.com/find?q={{repo}}", value: "team/api", want: "https://search.example.com/find?q=team%2Fapi"},
+		{url: "https://docs.example.com/page#{{repo}}", value: "a b", want: "https://docs.example.com/page#a%20b"},
+		{url: "https://{{repo}}.example.com/", value: "staging", want: "https://staging.example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			link := Link{Name: "test", URL: tt.url}
+			got, err := expandLinkURL(link, nil, map[string]string{"repo": tt.value}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// listOptions are the sorting, filtering and paging flags shared by
+// al note list and al link list
+type listOptions struct {
+	sort      string
+	reverse   bool
+	since     string
+	until     string
+	dateField string
+	encrypted bool
+	plain     bool
+	pinned    bool
+	limit     int
+}
+
+// listEntry is what listOptions needs to know about a listed note or link
+type listEntry struct {
+	project   string
+	name      string
+	createdAt time.Time
+	updatedAt time.Time
+	size      int
+	encrypted bool
+	pinned    bool
+}
+
+var listSortOrders = []string{"name", "created", "updated", "size"}
+
+// listDateFields are the dates --since and --until can filter on
+var listDateFields = []string{"updated", "created"}
+
+// addListFlags registers the listing flags on cmd. The encryption filters
+// are only added for items that can be encrypted.
+func addListFlags(cmd *cobra.Command, opts *listOptions, encryption bool) {
+	cmd.Flags().StringVar(&opts.sort, "sort", "", "Sort by name, created, updated or size (defaults to the 'sort' setting)")
+	cmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completionCandidates(listSortOrders, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().BoolVarP(&opts.reverse, "reverse", "r", false, "Reverse the sort order")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only list items updated (or created, with --date created) since a date (2006-01-02) or a duration (7d, 2w, 12h)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only list items updated (or created, with --date created) until a date or a duration")
+	cmd.Flags().StringVar(&opts.dateField, "date", "updated", "Date filtered by --since and --until: updated or created")
+	cmd.RegisterFlagCompletionFunc("date", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completionCandidates(listDateFields, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	if encryption {
+		cmd.Flags().BoolVar(&opts.encrypted, "encrypted", false, "Only list encrypted items")
+		cmd.Flags().BoolVar(&opts.plain, "plain", false, "Only list unencrypted items")
+		cmd.MarkFlagsMutuallyExclusive("encrypted", "plain")
+	}
+	cmd.Flags().BoolVar(&opts.pinned, "pinned", false, "Only list pinned items")
+	cmd.Flags().IntVarP(&opts.limit, "limit", "n", 0, "Maximum number of items to list (0 for no limit)")
+}
+
+// parseListDate reads a --since/--until value: a date, an RFC 3339 time or a
+// duration before now such as 7d, 2w or 12h. A plain date given as the upper
+// bound includes the whole day.
+func parseListDate(value string, now time.Time, upper bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if upper {
+			return t.AddDate(0, 0, 1), nil
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date '%s' (expected 2006-01-02, an RFC 3339 time or a duration such as 7d)", value)
+}
+
+// selectEntries filters and orders the entries and returns their indexes.
+// Entries stay grouped by project, in the order of the projects.
+func (o *listOptions) selectEntries(entries []listEntry, defaultSort string) ([]int, error) {
+	order := o.sort
+	if order == "" {
+		order = defaultSort
+	}
+	if !contains(listSortOrders, order) {
+		return nil, fmt.Errorf("unknown sort order '%s' (expected %s)", order, strings.Join(listSortOrders, ", "))
+	}
+
+	if o.limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d (expected a positive number, or 0 for no limit)", o.limit)
+	}
+
+	if !contains(listDateFields, o.dateField) {
+		return nil, fmt.Errorf("unknown date '%s' (expected %s)", o.dateField, strings.Join(listDateFields, ", "))
+	}
+
+	dateOf := func(e listEntry, field string) time.Time {
+		if field == "created" {
+			return e.createdAt
+		}
+		return e.updatedAt
+	}
+
+	now := time.Now()
+	var since, until time.Time
+	var err error
+	if o.since != "" {
+		if since, err = parseListDate(o.since, now, false); err != nil {
+			return nil, err
+		}
+	}
+	if o.until != "" {
+		if until, err = parseListDate(o.until, now, true); err != nil {
+			return nil, err
+		}
+	}
+
+	projectRank := make(map[string]int)
+	var selected []int
+	for i, e := range entries {
+		if _, ok := projectRank[e.project]; !ok {
+			projectRank[e.project] = len(projectRank)
+		}
+		if o.encrypted && !e.encrypted || o.plain && e.encrypted {
+			continue
+		}
+		if o.pinned && !e.pinned {
+			continue
+		}
+		if !since.IsZero() && dateOf(e, o.dateField).Before(since) {
+			continue
+		}
+		if !until.IsZero() && !dateOf(e, o.dateField).Before(until) {
+			continue
+		}
+		selected = append(selected, i)
+	}
+
+	less := func(a, b listEntry) bool {
+		switch order {
+		case "created", "updated":
+			return dateOf(a, order).Before(dateOf(b, order))
+		case "size":
+			return a.size < b.size
+		}
+		return strings.ToLower(a.name) < strings.ToLower(b.name)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := entries[selected[i]], entries[selected[j]]
+		if a.project != b.project {
+			return projectRank[a.project] < projectRank[b.project]
+		}
+		if o.reverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+
+	if o.limit > 0 && len(selected) > o.limit {
+		selected = selected[:o.limit]
+	}
+	return selected, nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseListDate(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		upper   bool
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2026-03-01", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		// A plain date as upper bound includes the whole day
+		{value: "2026-03-01", upper: true, want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)},
+		{value: "2026-03-01T08:30:00Z", want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "2026-03-01T08:30:00Z", upper: true, want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "7d", want: now.AddDate(0, 0, -7)},
+		{value: "0d", want: now},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "12h", want: now.Add(-12 * time.Hour)},
+		{value: "1h30m", want: now.Add(-90 * time.Minute)},
+		{value: "", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "-3d", wantErr: true},
+		{value: "-2h", wantErr: true},
+		{value: "3y", wantErr: true},
+		{value: "2026-13-01", wantErr: true},
+		{value: "d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseListDate(tt.value, now, tt.upper)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseListDate(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseListDate(%q, upper=%v) = %v, %v, want %v", tt.value, tt.upper, got, err, tt.want)
+		}
+	}
+}
+
+func TestSelectEntries(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	entries := []listEntry{
+		{project: "web", name: "deploy", createdAt: now.Add(-30 * day), updatedAt: now.Add(-1 * day), size: 300, pinned: true},
+		{project: "web", name: "Backup", createdAt: now.Add(-2 * day), updatedAt: now.Add(-2 * day), size: 100, encrypted: true},
+		{project: "web", name: "certs", createdAt: now.Add(-10 * day), updatedAt: now.Add(-10 * day), size: 200},
+		{project: "api", name: "access", createdAt: now.Add(-5 * day), updatedAt: now.Add(-3 * day), size: 50, encrypted: true, pinned: true},
+		{project: "api", name: "alerts", createdAt: now.Add(-1 * day), updatedAt: now.Add(-1 * day), size: 10},
+	}
+	names := func(indexes []int) []string {
+		out := []string{}
+		for _, i := range indexes {
+			out = append(out, entries[i].name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		opts listOptions
+		want []string
+	}{
+		{name: "default sort by name, grouped by project", want: []string{"Backup", "certs", "deploy", "access", "alerts"}},
+		{name: "reverse", opts: listOptions{reverse: true}, want: []string{"deploy", "certs", "Backup", "alerts", "access"}},
+		{name: "sort by size", opts: listOptions{sort: "size"}, want: []string{"Backup", "certs", "deploy", "alerts", "access"}},
+		{name: "sort by updated", opts: listOptions{sort: "updated"}, want: []string{"certs", "Backup", "deploy", "access", "alerts"}},
+		{name: "sort by created, reversed", opts: listOptions{sort: "created", reverse: true}, want: []string{"Backup", "certs", "deploy", "alerts", "access"}},
+		{name: "encrypted", opts: listOptions{encrypted: true}, want: []string{"Backup", "access"}},
+		{name: "plain", opts: listOptions{plain: true}, want: []string{"certs", "deploy", "alerts"}},
+		{name: "pinned", opts: listOptions{pinned: true}, want: []string{"deploy", "access"}},
+		{name: "updated since", opts: listOptions{since: "4d"}, want: []string{"Backup", "deploy", "access", "alerts"}},
+		{name: "created since", opts: listOptions{since: "4d", dateField: "created"}, want: []string{"Backup", "alerts"}},
+		{name: "updated until", opts: listOptions{until: "4d"}, want: []string{"certs"}},
+		{name: "since and until", opts: listOptions{since: "4d", until: "36h"}, want: []string{"Backup", "access"}},
+		{name: "limit", opts: listOptions{limit: 2}, want: []string{"Backup", "certs"}},
+		{name: "filters then limit", opts: listOptions{plain: true, sort: "updated", reverse: true, limit: 2}, want: []string{"deploy", "certs"}},
+		{name: "nothing selected", opts: listOptions{pinned: true, plain: true, since: "12h"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts.dateField == "" {
+				opts.dateField = "updated"
+			}
+			got, err := opts.selectEntries(entries, "name")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("got %v, want %v", names(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectEntriesErrors(t *testing.T) {
+	tests := []struct {
+		opts    listOptions
+		wantErr string
+	}{
+		{opts: listOptions{sort: "size", limit: -1, dateField: "updated"}, wantErr: "invalid limit -1"},
+		{opts: listOptions{sort: "random", dateField: "updated"}, wantErr: "unknown sort order 'random'"},
+		{opts: listOptions{dateField: "modified"}, wantErr: "unknown date 'modified'"},
+		{opts: listOptions{since: "soon", dateField: "updated"}, wantErr: "invalid date 'soon'"},
+		{opts: listOptions{until: "later", dateField: "updated"}, wantErr: "invalid date 'later'"},
+	}
+
+	for _, tt := range tests {
+		_, err := tt.opts.selectEntries(nil, "name")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("selectEntries(%+v) = %v, want %q", tt.opts, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alex/al/utils"
+)
+
+// Note formats
+const (
+	noteFormatPlain    = "plain"
+	noteFormatMarkdown = "markdown"
+)
+
+var noteFormats = []string{noteFormatPlain, noteFormatMarkdown}
+
+// ANSI styles used by the Markdown renderer
+const (
+	ansiReset     = "\033[0m"
+	ansiBold      = "\033[1m"
+	ansiDim       = "\033[2m"
+	ansiItalic    = "\033[3m"
+	ansiUnderline = "\033[4m"
+	ansiBlue      = "\033[34m"
+	ansiMagenta   = "\033[35m"
+	ansiCyan      = "\033[36m"
+	ansiYellow    = "\033[33m"
+	ansiRed       = "\033[31m"
+)
+
+// useColor reports whether output to stdout may contain ANSI colours
+func useColor() bool {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return !noColor && utils.IsTerminal(os.Stdout)
+}
+
+// markdownRenderer renders Markdown for the terminal, with or without
+// colours. The [[references]] are resolved by refs when it is set.
+type markdownRenderer struct {
+	color bool
+	refs  *refResolver
+}
+
+func (r markdownRenderer) style(text string, styles ...string) string {
+	if !r.color || text == "" {
+		return text
+	}
+	return strings.Join(styles, "") + text + ansiReset
+}
+
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdFence     = regexp.MustCompile("^(\\s*)(```+|~~~+)\\s*([^`\\s]*)")
+	mdBullet    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdOrdered   = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	mdTask      = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdQuote     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdRule      = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdTableRow  = regexp.MustCompile(`^\s*\|.*\|\s*$`)
+	mdTableSep  = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)+\s*(:?-+:?\s*)?$`)
+	mdCodeSpan  = regexp.MustCompile("`([^`]+)`")
+	mdLink      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic    = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*|(^|[^_\w])_([^_\s][^_]*)_`)
+	ruleDivider = strings.Repeat("─", 40)
+)
+
+// isFenceEnd reports whether line closes a code block opened with fence: a
+// line holding only the fence character, at least as many times
+func isFenceEnd(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// unindentCode removes from a line of a code block the indentation of its
+// fence, so that blocks nested in lists run as written
+func unindentCode(line string, indent int) string {
+	for i := 0; i < indent && line != "" && (line[0] == ' ' || line[0] == '\t'); i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+// inline renders the emphasis, code spans and links of a line
+func (r markdownRenderer) inline(line string) string {
+	// Code spans and references are replaced first so their content is left
+	// untouched
+	var spans []string
+	line = mdCodeSpan.ReplaceAllStringFunc(line, func(m string) string {
+		spans = append(spans, r.style(mdCodeSpan.FindStringSubmatch(m)[1], ansiYellow))
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+	if r.refs != nil {
+		line = wikiRef.ReplaceAllStringFunc(line, func(m string) string {
+			spans = append(spans, r.refs.render(r, wikiRef.FindStringSubmatch(m)[1]))
+			return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+		})
+	}
+
+	line = mdLink.ReplaceAllStringFunc(line, func(m string) string {
+		parts := mdLink.FindStringSubmatch(m)
+		if parts[1] == parts[2] {
+			return r.style(parts[2], ansiBlue, ansiUnderline)
+		}
+		return parts[1] + " (" + r.style(parts[2], ansiBlue, ansiUnderline) + ")"
+	})
+	line = mdBold.ReplaceAllStringFunc(line, func(m string) string {
+		parts := mdBold.FindStringSubmatch(m)
+		return r.style(parts[1]+parts[2], ansiBold)
+	})
+	line = mdItalic.ReplaceAllStringFunc(line, func(m string) string {
+		parts := mdItalic.FindStringSubmatch(m)
+		return parts[1] + parts[3] + r.style(parts[2]+parts[4], ansiItalic)
+	})
+
+	for i, span := range spans {
+		line = strings.Replace(line, fmt.Sprintf("\x00%d\x00", i), span, 1)
+	}
+	return line
+}
+
+// table renders the rows of a Markdown table with aligned columns
+func (r markdownRenderer) table(rows []string) []string {
+	var cells [][]string
+	for i, row := range rows {
+		if i == 1 && mdTableSep.MatchString(row) {
+			continue
+		}
+		row = strings.TrimSpace(row)
+		row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+		var cols []string
+		for _, cell := range strings.Split(row, "|") {
+			cols = append(cols, r.inline(strings.TrimSpace(cell)))
+		}
+		cells = append(cells, cols)
+	}
+
+	var widths []int
+	for _, cols := range cells {
+		for j, cell := range cols {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], visibleWidth(cell))
+		}
+	}
+
+	var out []string
+	for i, cols := range cells {
+		var b strings.Builder
+		for j, width := range widths {
+			cell := ""
+			if j < len(cols) {
+				cell = cols[j]
+			}
+			if i == 0 {
+				cell = r.style(cell, ansiBold)
+			}
+			if j > 0 {
+				b.WriteString(" │ ")
+			}
+			b.WriteString(cell + strings.Repeat(" ", width-visibleWidth(cell)))
+		}
+		out = append(out, "  "+strings.TrimRight(b.String(), " "))
+
+		if i == 0 {
+			var sep []string
+			for _, width := range widths {
+				sep = append(sep, strings.Repeat("─", width))
+			}
+			out = append(out, "  "+strings.Join(sep, "─┼─"))
+		}
+	}
+	return out
+}
+
+var ansiSequence = regexp.MustCompile(`\033\[[0-9;]*m`)
+
+// visibleWidth is the number of characters of s once printed
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(s, ""))
+}
+
+// render converts a Markdown document into terminal text
+func (r markdownRenderer) render(content string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			indent, fence, label := m[1], m[2], m[3]
+			if label != "" {
+				out = append(out, indent+r.style("  "+label, ansiDim))
+			}
+			for i++; i < len(lines) && !isFenceEnd(lines[i], fence); i++ {
+				out = append(out, indent+"    "+r.style(unindentCode(lines[i], len(indent)), ansiCyan))
+			}
+			continue
+		}
+
+		if mdTableRow.MatchString(line) {
+			start := i
+			for i+1 < len(lines) && mdTableRow.MatchString(lines[i+1]) {
+				i++
+			}
+			out = append(out, r.table(lines[start:i+1])...)
+			continue
+		}
+
+		switch {
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			text := r.inline(m[2])
+			if len(m[1]) == 1 {
+				out = append(out, r.style(strings.ToUpper(text), ansiBold, ansiMagenta))
+			} else {
+				out = append(out, r.style(text, ansiBold, ansiMagenta))
+			}
+		case mdRule.MatchString(line):
+			out = append(out, r.style(ruleDivider, ansiDim))
+		case mdQuote.MatchString(line):
+			out = append(out, r.style("│ ", ansiDim)+r.style(r.inline(mdQuote.FindStringSubmatch(line)[1]), ansiItalic))
+		case mdBullet.MatchString(line):
+			m := mdBullet.FindStringSubmatch(line)
+			bullet, text := "•", m[2]
+			if task := mdTask.FindStringSubmatch(text); task != nil {
+				bullet, text = "☐", task[2]
+				if task[1] != " " {
+					bullet = "☑"
+				}
+			}
+			out = append(out, m[1]+"  "+r.style(bullet, ansiCyan)+" "+r.inline(text))
+		case mdOrdered.MatchString(line):
+			m := mdOrdered.FindStringSubmatch(line)
+			out = append(out, m[1]+"  "+r.style(m[2]+".", ansiCyan)+" "+r.inline(m[3]))
+		default:
+			out = append(out, r.inline(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderNoteContent returns the content of a note as printed by al note get,
+// with its references resolved
+func renderNoteContent(projectPath string, note *Note, content string) string {
+	renderer := markdownRenderer{color: useColor(), refs: &refResolver{projectPath: projectPath}}
+	if note.Format != noteFormatMarkdown {
+		return renderer.refs.renderRefs(renderer, content)
+	}
+	return renderer.render(content)
+}
+
+// codeBlock is a fenced code block of a Markdown note
+type codeBlock struct {
+	Lang string
+	Code string
+}
+
+// codeBlocks returns the fenced code blocks of a Markdown document in order.
+// A block left open runs to the end of the document.
+func codeBlocks(content string) []codeBlock {
+	var blocks []codeBlock
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := mdFence.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		var code []string
+		for i++; i < len(lines) && !isFenceEnd(lines[i], m[2]); i++ {
+			code = append(code, unindentCode(lines[i], len(m[1])))
+		}
+		blocks = append(blocks, codeBlock{Lang: strings.ToLower(m[3]), Code: strings.Join(code, "\n")})
+	}
+	return blocks
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []codeBlock
+	}{
+		{
+			name:    "backtick and tilde fences",
+			content: "intro\n```sh\necho one\n```\ntext\n~~~Python\nprint(1)\n~~~\n",
+			want:    []codeBlock{{Lang: "sh", Code: "echo one"}, {Lang: "python", Code: "print(1)"}},
+		},
+		{
+			name:    "no language",
+			content: "```\nplain\n\nlines\n```",
+			want:    []codeBlock{{Lang: "", Code: "plain\n\nlines"}},
+		},
+		{
+			name:    "fence inside a list",
+			content: "1. Build\n   ```bash\n   make build\n     indented\n   ```\n2. Deploy\n   - nested\n     ~~~sh\n     ./deploy.sh\n     ~~~",
+			want:    []codeBlock{{Lang: "bash", Code: "make build\n  indented"}, {Lang: "sh", Code: "./deploy.sh"}},
+		},
+		{
+			name:    "unterminated fence runs to the end",
+			content: "```sh\necho one\necho two",
+			want:    []codeBlock{{Lang: "sh", Code: "echo one\necho two"}},
+		},
+		{
+			name:    "other fences inside a block",
+			content: "````md\n```sh\necho nested\n```\n~~~\n````\n```sh\necho after\n```",
+			want:    []codeBlock{{Lang: "md", Code: "```sh\necho nested\n```\n~~~"}, {Lang: "sh", Code: "echo after"}},
+		},
+		{
+			name:    "a longer closing fence closes, an info string does not",
+			content: "```sh\necho a\n```sh\necho b\n`````\n",
+			want:    []codeBlock{{Lang: "sh", Code: "echo a\n```sh\necho b"}},
+		},
+		{
+			name:    "inline backticks are not fences",
+			content: "run `make` then ``x``",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeBlocks(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownInline(t *testing.T) {
+	plain := markdownRenderer{}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "**bold** and *italic* and _also_", want: "bold and italic and also"},
+		{in: "[docs](https://example.com)", want: "docs (https://example.com)"},
+		{in: "[https://example.com](https://example.com)", want: "https://example.com"},
+		{in: "`a*b*c` and `__init__`", want: "a*b*c and __init__"},
+		{in: "`**not bold**` but **bold**", want: "**not bold** but bold"},
+		{in: "`[[ -f x ]]` tests a file", want: "[[ -f x ]] tests a file"},
+		{in: "`[x](y)`", want: "[x](y)"},
+		{in: "snake_case_name stays", want: "snake_case_name stays"},
+		{in: "2 * 3 * 4", want: "2 * 3 * 4"},
+		{in: "`one` and `two` and `three`", want: "one and two and three"},
+	}
+
+	for _, tt := range tests {
+		if got := plain.inline(tt.in); got != tt.want {
+			t.Errorf("inline(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	colored := markdownRenderer{color: true}
+	got := colored.inline("`a*b*` *c*")
+	want := ansiYellow + "a*b*" + ansiReset + " " + ansiItalic + "c" + ansiReset
+	if got != want {
+		t.Errorf("colored inline = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownRender(t *testing.T) {
+	content := strings.Join([]string{
+		"# Deploy",
+		"## Steps",
+		"- [ ] check `[[ -f .env ]]`",
+		"- [x] build",
+		"1. run:",
+		"   ```sh",
+		"   make deploy",
+		"   ```",
+		"> careful",
+		"---",
+		"| a | bb |",
+		"|---|----|",
+		"| ccc | d |",
+		"~~~",
+		"**raw** [[ref]]",
+	}, "\n")
+
+	want := strings.Join([]string{
+		"DEPLOY",
+		"Steps",
+		"  ☐ check [[ -f .env ]]",
+		"  ☑ build",
+		"  1. run:",
+		"     sh",
+		"       make deploy",
+		"│ careful",
+		ruleDivider,
+		"  a   │ bb",
+		"  ────┼───",
+		"  ccc │ d",
+		"    **raw** [[ref]]",
+	}, "\n")
+
+	if got := (markdownRenderer{}).render(content); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if useColor() {
+		t.Error("NO_COLOR set, even empty, must disable colours")
+	}
+
+	// Test output is not a terminal
+	captureStdout(t, func() {
+		if useColor() {
+			t.Error("colours enabled on a pipe")
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type migrateOutput struct {
+	Project string `json:"project"`
+	Kind    string `json:"kind"`
+	File    string `json:"file"`
+	Target  string `json:"target"`
+	Result  string `json:"result"`
+}
+
+var (
+	migrateTarget      string
+	migrateGlobal      bool
+	migrateAllProjects bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rename the note and link files written by older versions",
+	Long: `Rename the note and link files written by older versions of al, which were
+named after the raw item name, to their slugified name. A file is never
+overwritten: when two items map to the same file, for instance names only
+differing in case, the second one keeps its name and is reported as a
+conflict. Rename one of them with al note mv, then run al migrate again.
+
+Example: al migrate
+         al migrate --all-projects`,
+	Args: cobra.NoArgs,
+	RunE: runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().StringVarP(&migrateTarget, "target", "t", "", "Target project")
+	migrateCmd.RegisterFlagCompletionFunc("target", completeTargets)
+	migrateCmd.Flags().BoolVarP(&migrateGlobal, "global", "g", false, "Migrate the global notes and links")
+	migrateCmd.Flags().BoolVar(&migrateAllProjects, "all-projects", false, "Migrate every project and client, and the global notes and links")
+	migrateCmd.MarkFlagsMutuallyExclusive("all-projects", "target")
+	migrateCmd.MarkFlagsMutuallyExclusive("all-projects", "global")
+}
+
+// migrateItemFiles renames the files of dir whose name is not the slugified
+// name of the item they hold. readName returns that name.
+func migrateItemFiles(scope, kind, dir string, readName func(path string) (string, error)) ([]migrateOutput, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var out []migrateOutput
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name, err := readName(path)
+		if err != nil {
+			continue
+		}
+
+		target := itemFileName(name)
+		if target == entry.Name() {
+			continue
+		}
+		result := "renamed"
+		renamed, err := migrateItemFile(dir, path, name)
+		if err != nil {
+			return nil, err
+		}
+		if !renamed {
+			result = "conflict"
+		}
+		out = append(out, migrateOutput{Project: scope, Kind: kind, File: entry.Name(), Target: target, Result: result})
+	}
+	return out, nil
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	var scopes []namedProject
+	if migrateAllProjects {
+		var err error
+		if scopes, err = allScopes(); err != nil {
+			return err
+		}
+	} else {
+		path, err := resolveScopePath(migrateTarget, migrateGlobal)
+		if err != nil {
+			return err
+		}
+		scopes = []namedProject{{name: scopeLabel(path), path: path}}
+	}
+
+	report := []migrateOutput{}
+	for _, scope := range scopes {
+		notes, err := migrateItemFiles(scope.name, "note", getNotesDir(scope.path), func(path string) (string, error) {
+			note, err := readNoteFile(path)
+			if err != nil {
+				return "", err
+			}
+			return note.Name, nil
+		})
+		if err != nil {
+			return err
+		}
+		links, err := migrateItemFiles(scope.name, "link", getLinksDir(scope.path), func(path string) (string, error) {
+			link, err := readLinkFile(path)
+			if err != nil {
+				return "", err
+			}
+			return link.Name, nil
+		})
+		if err != nil {
+			return err
+		}
+		report = append(report, notes...)
+		report = append(report, links...)
+	}
+
+	if isStructuredOutput() {
+		return printOutput(report)
+	}
+
+	if len(report) == 0 {
+		fmt.Println("✓ Nothing to migrate")
+		return nil
+	}
+
+	renamed, conflicts := 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Project\tKind\tFile\tResult")
+	fmt.Fprintln(w, "-------\t----\t----\t------")
+	for _, r := range report {
+		if r.Result == "renamed" {
+			renamed++
+			fmt.Fprintf(w, "%s\t%s\t%s\trenamed to %s\n", r.Project, r.Kind, r.File, r.Target)
+		} else {
+			conflicts++
+			fmt.Fprintf(w, "%s\t%s\t%s\tconflict: %s already exists\n", r.Project, r.Kind, r.File, r.Target)
+		}
+	}
+	w.Flush()
+	fmt.Println()
+
+	fmt.Printf("✓ %d files renamed", renamed)
+	if conflicts > 0 {
+		fmt.Printf(", %d conflicts left as is", conflicts)
+	}
+	fmt.Println()
+	return nil
+}
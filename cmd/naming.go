@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/alex/al/utils"
+)
+
+// Longest file name stem, file systems limit names to 255 bytes
+const maxFileNameRunes = 100
+
+// Device names that cannot be used as file names on Windows
+var reservedFileNames = []string{"con", "prn", "aux", "nul",
+	"com1", "com2", "com3", "com4", "com5", "com6", "com7", "com8", "com9",
+	"lpt1", "lpt2", "lpt3", "lpt4", "lpt5", "lpt6", "lpt7", "lpt8", "lpt9"}
+
+// validateName rejects the note and link names that cannot be stored: empty
+// names, names without any letter or digit, '.' and '..' path segments and
+// control characters. Any other Unicode character is accepted, the display
+// name is kept in the JSON file.
+func validateName(kind, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%s name cannot be empty", kind)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("invalid %s name %q: control characters are not allowed", kind, name)
+		}
+	}
+	for _, segment := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("invalid %s name '%s': '.' and '..' are not allowed", kind, name)
+		}
+	}
+	if utils.Slugify(name) == "" {
+		return fmt.Errorf("invalid %s name '%s': it must contain a letter or a digit", kind, name)
+	}
+	return nil
+}
+
+// itemFileName returns the name of the file storing a note or link: its
+// slugified name, so that any name maps to a single safe file
+func itemFileName(name string) string {
+	slug := []rune(utils.Slugify(strings.TrimPrefix(name, "#")))
+	if len(slug) > maxFileNameRunes {
+		slug = slug[:maxFileNameRunes]
+	}
+	stem := strings.TrimRight(string(slug), "-")
+	if contains(reservedFileNames, stem) {
+		stem += "-"
+	}
+	return stem + ".json"
+}
+
+// itemFilePath returns the file of the note or link named name in dir.
+// Files written by older versions are named after the raw name: they are
+// still found, and renamed by al migrate.
+func itemFilePath(dir, name string) string {
+	name = strings.TrimPrefix(name, "#")
+	path := filepath.Join(dir, itemFileName(name))
+
+	// Never join a name that could escape dir
+	if validateName("item", name) != nil || strings.ContainsAny(name, `/\`) {
+		return path
+	}
+	legacy := filepath.Join(dir, name+".json")
+	if legacy == path {
+		return path
+	}
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return path
+}
+
+// sameItemName reports whether the name stored in a file answers a lookup of
+// name. Several names share a file ("Foo Bar" and "foo-bar" are both stored in
+// foo-bar.json), so only the case and the # prefix are ignored.
+func sameItemName(stored, name string) bool {
+	return strings.EqualFold(strings.TrimSpace(stored), strings.TrimSpace(strings.TrimPrefix(name, "#")))
+}
+
+// nameCollisionError is returned when the file of a name holds an item saved
+// under another name
+type nameCollisionError struct {
+	kind   string
+	name   string
+	stored string
+}
+
+func (e *nameCollisionError) Error() string {
+	return fmt.Sprintf("%s '%s' not found: its file %s holds the %s '%s'", e.kind, e.name, itemFileName(e.name), e.kind, e.stored)
+}
+
+// itemExistsError rejects a new item whose file is already used. The message
+// names the existing item when its name only maps to the same file.
+func itemExistsError(kind, name, existing string) error {
+	if sameItemName(existing, name) {
+		return fmt.Errorf("%s '%s' already exists", kind, existing)
+	}
+	return fmt.Errorf("%s '%s' already exists and is stored in the same file as '%s' (%s)", kind, existing, name, itemFileName(name))
+}
+
+// sameItemFile reports whether two paths are the same file. Case insensitive
+// file systems store Foo.json and foo.json as a single file.
+func sameItemFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// migrateItemFile renames a file of dir to the slugified name of the item it
+// holds. It returns false, leaving the file as is, when another file already
+// uses that name.
+func migrateItemFile(dir, path, name string) (bool, error) {
+	target := filepath.Join(dir, itemFileName(name))
+	if target == path {
+		return true, nil
+	}
+	if _, err := os.Stat(target); err == nil && !sameItemFile(path, target) {
+		return false, nil
+	}
+	if err := os.Rename(path, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "deploy"},
+		{name: "Deploy Prod"},
+		{name: "été 2026"},
+		{name: "docs/guide"},
+		{name: "con"},
+		{name: "", wantErr: "cannot be empty"},
+		{name: "   ", wantErr: "cannot be empty"},
+		{name: "..", wantErr: "'.' and '..' are not allowed"},
+		{name: "../secret", wantErr: "'.' and '..' are not allowed"},
+		{name: `docs\..\x`, wantErr: "'.' and '..' are not allowed"},
+		{name: "a/./b", wantErr: "'.' and '..' are not allowed"},
+		{name: "tab\there", wantErr: "control characters"},
+		{name: "line\nbreak", wantErr: "control characters"},
+		{name: "---", wantErr: "must contain a letter or a digit"},
+		{name: "/", wantErr: "must contain a letter or a digit"},
+	}
+
+	for _, tt := range tests {
+		err := validateName("note", tt.name)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateName(%q) = %v, want no error", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateName(%q) = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestItemFileName(t *testing.T) {
+	long := strings.Repeat("a", 150)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "deploy", want: "deploy.json"},
+		{name: "#Deploy Prod", want: "deploy-prod.json"},
+		{name: "FOO_BAR", want: "foo-bar.json"},
+		{name: "été 2026", want: "été-2026.json"},
+		{name: "../../etc/passwd", want: "etc-passwd.json"},
+		{name: `C:\Windows`, want: "c-windows.json"},
+		{name: "con", want: "con-.json"},
+		{name: "LPT1", want: "lpt1-.json"},
+		{name: "console", want: "console.json"},
+		{name: long, want: strings.Repeat("a", maxFileNameRunes) + ".json"},
+		// The cap counts runes, not bytes
+		{name: strings.Repeat("é", 150), want: strings.Repeat("é", maxFileNameRunes) + ".json"},
+		// A dash left at the cut is dropped
+		{name: strings.Repeat("a", 99) + " b", want: strings.Repeat("a", 99) + ".json"},
+	}
+
+	for _, tt := range tests {
+		if got := itemFileName(tt.name); got != tt.want {
+			t.Errorf("itemFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// writeItemFile writes a note file holding name under fileName
+func writeItemFile(t *testing.T, dir, fileName, name string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(Note{Name: name})
+	path := filepath.Join(dir, fileName)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestItemFilePath(t *testing.T) {
+	dir := t.TempDir()
+	writeItemFile(t, dir, "Deploy Prod.json", "Deploy Prod")
+	writeItemFile(t, filepath.Dir(dir), "outside.json", "outside")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		// Files written by older versions are still found
+		{name: "Deploy Prod", want: "Deploy Prod.json"},
+		{name: "#Deploy Prod", want: "Deploy Prod.json"},
+		{name: "deploy prod", want: "deploy-prod.json"},
+		{name: "new note", want: "new-note.json"},
+		// Never a legacy path outside dir
+		{name: "../outside", want: "outside.json"},
+		{name: "a/b", want: "a-b.json"},
+	}
+
+	for _, tt := range tests {
+		if got := itemFilePath(dir, tt.name); got != filepath.Join(dir, tt.want) {
+			t.Errorf("itemFilePath(%q) = %q, want %q", tt.name, got, filepath.Join(dir, tt.want))
+		}
+	}
+}
+
+func TestMigrateItemFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := writeItemFile(t, dir, "Deploy.json", "Deploy")
+	conflict := writeItemFile(t, dir, "Backup.json", "Backup")
+	writeItemFile(t, dir, "backup.json", "backup")
+
+	renamed, err := migrateItemFile(dir, legacy, "Deploy")
+	if err != nil || !renamed {
+		t.Fatalf("migrateItemFile = %v, %v, want a rename", renamed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "deploy.json")); err != nil {
+		t.Error("deploy.json not created")
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("Deploy.json left behind")
+	}
+
+	renamed, err = migrateItemFile(dir, conflict, "Backup")
+	if err != nil || renamed {
+		t.Fatalf("migrateItemFile = %v, %v, want a conflict", renamed, err)
+	}
+	note, err := readNoteFile(filepath.Join(dir, "backup.json"))
+	if err != nil || note.Name != "backup" {
+		t.Errorf("backup.json overwritten: %+v, %v", note, err)
+	}
+
+	// On case insensitive file systems the target is the file itself
+	same := writeItemFile(t, dir, "Same.json", "Same")
+	if err := os.Link(same, filepath.Join(dir, "same.json")); err != nil {
+		t.Fatal(err)
+	}
+	if renamed, err := migrateItemFile(dir, same, "Same"); err != nil || !renamed {
+		t.Errorf("migrateItemFile = %v, %v, want a rename of the file itself", renamed, err)
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	setupTestHome(t)
+	t.Cleanup(func() { migrateGlobal, outputFormat = false, "" })
+	migrateGlobal, outputFormat = true, "json"
+
+	notes := getNotesDir("")
+	writeItemFile(t, notes, "Deploy.json", "Deploy")
+	writeItemFile(t, notes, "deploy.json", "deploy")
+	writeItemFile(t, notes, "Runbook Prod.json", "Runbook Prod")
+	writeItemFile(t, notes, "ok.json", "ok")
+	if err := os.WriteFile(filepath.Join(notes, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := runMigrate(migrateCmd, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var report []migrateOutput
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	want := map[string]string{"Deploy.json": "conflict", "Runbook Prod.json": "renamed"}
+	if len(report) != len(want) {
+		t.Fatalf("report = %+v, want %v", report, want)
+	}
+	for _, r := range report {
+		if want[r.File] != r.Result || r.Project != globalScopeName || r.Kind != "note" {
+			t.Errorf("unexpected entry %+v", r)
+		}
+	}
+	if _, err := loadNote("", "Runbook Prod"); err != nil {
+		t.Errorf("renamed note not found: %v", err)
+	}
+}
+
+func TestLoadNoteNameCollision(t *testing.T) {
+	setupTestHome(t)
+	if err := saveNote("", &Note{Name: "Foo Bar", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Foo Bar", "#foo bar", "FOO BAR"} {
+		if note, err := loadNote("", name); err != nil || note.Name != "Foo Bar" {
+			t.Errorf("loadNote(%q) = %v, %v", name, note, err)
+		}
+	}
+
+	for _, name := range []string{"foo-bar", "FOO_BAR"} {
+		_, err := loadNote("", name)
+		var collision *nameCollisionError
+		if !errors.As(err, &collision) || collision.stored != "Foo Bar" {
+			t.Errorf("loadNote(%q) = %v, want a name collision", name, err)
+		}
+		if _, err := loadNoteFile("", name); err != nil {
+			t.Errorf("loadNoteFile(%q) = %v, want the note sharing the file", name, err)
+		}
+	}
+
+	err := itemExistsError("note", "foo-bar", "Foo Bar")
+	if !strings.Contains(err.Error(), "same file as 'foo-bar' (foo-bar.json)") {
+		t.Errorf("itemExistsError = %v", err)
+	}
+	if err := itemExistsError("note", "foo bar", "Foo Bar"); err.Error() != "note 'Foo Bar' already exists" {
+		t.Errorf("itemExistsError = %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,11 +17,16 @@ import (
 )
 
 type Note struct {
-	Name      string    `json:"name"`
-	Content   string    `json:"content"`
-	Encrypted bool      `json:"encrypted"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Name         string       `json:"name"`
+	Content      string       `json:"content"`
+	Encrypted    bool         `json:"encrypted"`
+	Format       string       `json:"format,omitempty"`
+	Binary       bool         `json:"binary,omitempty"`
+	Attachments  []Attachment `json:"attachments,omitempty"`
+	Pinned       bool         `json:"pinned,omitempty"`
+	PinnedBlocks []string     `json:"pinned_blocks,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
 var (
@@ -27,12 +34,18 @@ var (
 	noteEncrypted bool
 	noteBody      string
 	noteCopy      bool
+	noteGlobal    bool
+	noteListAll   bool
+	noteListOpts  listOptions
+	noteFormat    string
+	noteRaw       bool
+	noteTemplate  string
 )
 
 var noteCmd = &cobra.Command{
 	Use:   "note [action] [name]",
 	Short: "Manage notes for projects",
-	Long:  `Manage notes for projects. Actions: list, add, get, edit, remove`,
+	Long:  `Manage notes for projects. Actions: list, add, get, edit, append, remove, mv, cp, run, attach, attachments, extract, detach, backlinks, check, pin, unpin`,
 }
 
 var noteListCmd = &cobra.Command{
@@ -42,76 +55,120 @@ var noteListCmd = &cobra.Command{
 }
 
 var noteAddCmd = &cobra.Command{
-	Use:   "add [#name]",
+	Use:   "add [#name] [-]",
 	Short: "Add a new note",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runNoteAdd,
+	Long: `Add a new note, typed in the editor, given with -b, read from the standard
+input with '-' or from a file with --from-file. Content that is not text
+is stored as binary and written back as is by al note get.
+
+Example: al note add #todo
+         kubectl get pods | al note add #pods -
+         al note add #vpn --from-file client.ovpn -c`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runNoteAdd,
 }
 
 var noteGetCmd = &cobra.Command{
-	Use:   "get [#name]",
-	Short: "Get a note",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runNoteGet,
+	Use:               "get [#name]",
+	Short:             "Get a note",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteGet,
 }
 
 var noteEditCmd = &cobra.Command{
-	Use:   "edit [#name]",
-	Short: "Edit a note",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runNoteEdit,
+	Use:               "edit [#name]",
+	Short:             "Edit a note",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteEdit,
 }
 
 var noteRemoveCmd = &cobra.Command{
-	Use:   "remove [#name]",
-	Short: "Remove a note",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runNoteRemove,
+	Use:               "remove [#name]",
+	Short:             "Remove a note",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteRemove,
 }
 
 func init() {
 	// Add flags
 	noteCmd.PersistentFlags().StringVarP(&noteTarget, "target", "t", "", "Target project")
+	noteCmd.RegisterFlagCompletionFunc("target", completeTargets)
+	noteCmd.PersistentFlags().BoolVarP(&noteGlobal, "global", "g", false, "Use the global notes (outside of any project)")
 	noteAddCmd.Flags().BoolVarP(&noteEncrypted, "chiffre", "c", false, "Encrypt the note")
 	noteAddCmd.Flags().StringVarP(&noteBody, "body", "b", "", "Note body (no editor)")
+	noteAddCmd.Flags().StringVar(&noteTemplate, "template", "", "Prefill the editor with a note template (see al template)")
+	noteAddCmd.RegisterFlagCompletionFunc("template", completeNoteTemplates)
+	noteAddCmd.MarkFlagsMutuallyExclusive("body", "template")
 	noteEditCmd.Flags().StringVarP(&noteBody, "body", "b", "", "Note body (no editor)")
+	for _, c := range []*cobra.Command{noteAddCmd, noteEditCmd} {
+		c.Flags().StringVarP(&noteFormat, "format", "f", "", "Note format: plain or markdown (defaults to the 'note_format' setting)")
+		c.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completionCandidates(noteFormats, toComplete), cobra.ShellCompDirectiveNoFileComp
+		})
+	}
 	noteGetCmd.Flags().BoolVar(&noteCopy, "cp", false, "Copy to clipboard")
+	noteGetCmd.Flags().BoolVar(&noteRaw, "raw", false, "Print Markdown notes without rendering them")
+	noteListCmd.Flags().BoolVar(&noteListAll, "all-projects", false, "List the notes of every project and client")
+	addListFlags(noteListCmd, &noteListOpts, true)
 
 	// Add subcommands
 	noteCmd.AddCommand(noteListCmd)
+	markAllProjectsExclusive(noteListCmd)
 	noteCmd.AddCommand(noteAddCmd)
 	noteCmd.AddCommand(noteGetCmd)
 	noteCmd.AddCommand(noteEditCmd)
 	noteCmd.AddCommand(noteRemoveCmd)
 }
 
+// getProjectPath returns the project of the notes, or an empty path for the
+// global notes
 func getProjectPath() (string, error) {
-	if noteTarget != "" {
-		_, project, err := storage.FindProjectByShortcut(noteTarget)
-		if err != nil {
-			return "", fmt.Errorf("project '%s' not found", noteTarget)
-		}
-		return project.Path, nil
-	}
-
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	return cwd, nil
+	return resolveScopePath(noteTarget, noteGlobal)
 }
 
 func getNotesDir(projectPath string) string {
-	return filepath.Join(storage.GetLocalDir(projectPath), "notes")
+	return filepath.Join(storage.GetDataDir(projectPath), "notes")
 }
 
+// getNoteFilePath returns the file of a note, named after its slugified name
 func getNoteFilePath(projectPath, noteName string) string {
-	noteName = strings.TrimPrefix(noteName, "#")
-	return filepath.Join(getNotesDir(projectPath), noteName+".json")
+	return itemFilePath(getNotesDir(projectPath), noteName)
 }
 
+// loadNote loads the note named noteName. Another note stored in the same
+// file is reported as a nameCollisionError.
 func loadNote(projectPath, noteName string) (*Note, error) {
-	notePath := getNoteFilePath(projectPath, noteName)
+	note, err := loadNoteFile(projectPath, noteName)
+	if err != nil {
+		return nil, err
+	}
+	if !sameItemName(note.Name, noteName) {
+		return nil, &nameCollisionError{kind: "note", name: strings.TrimPrefix(noteName, "#"), stored: note.Name}
+	}
+	return note, nil
+}
+
+// loadNoteFile loads the note stored in the file of noteName, whatever its
+// name: it tells whether a new note can use that file
+func loadNoteFile(projectPath, noteName string) (*Note, error) {
+	return readNoteFile(getNoteFilePath(projectPath, noteName))
+}
+
+// reportNoteNotFound reports a note that loadNote could not load, with the
+// similar names or the note sharing its file
+func reportNoteNotFound(projectPath, noteName string, err error) error {
+	var collision *nameCollisionError
+	if errors.As(err, &collision) {
+		return collision
+	}
+	similar, _ := findSimilarNotes(projectPath, noteName, 3)
+	return reportNotFound("note", noteName, similar)
+}
+
+func readNoteFile(notePath string) (*Note, error) {
 	data, err := os.ReadFile(notePath)
 	if err != nil {
 		return nil, err
@@ -126,6 +183,12 @@ func loadNote(projectPath, noteName string) (*Note, error) {
 }
 
 func saveNote(projectPath string, note *Note) error {
+	if err := ensureDataDir(getNotesDir(projectPath)); err != nil {
+		return err
+	}
+
+	unpinChangedBlocks(note)
+
 	notePath := getNoteFilePath(projectPath, note.Name)
 	data, err := json.MarshalIndent(note, "", "  ")
 	if err != nil {
@@ -152,8 +215,8 @@ func listNotes(projectPath string) ([]Note, error) {
 			continue
 		}
 
-		noteName := strings.TrimSuffix(entry.Name(), ".json")
-		note, err := loadNote(projectPath, noteName)
+		notePath := filepath.Join(notesDir, entry.Name())
+		note, err := readNoteFile(notePath)
 		if err != nil {
 			continue
 		}
@@ -163,6 +226,16 @@ func listNotes(projectPath string) ([]Note, error) {
 	return notes, nil
 }
 
+// noteTempFile is the file edited in the editor, with a .md extension for
+// Markdown notes so that editors highlight them
+func noteTempFile(noteName, format string) string {
+	ext := ".txt"
+	if format == noteFormatMarkdown {
+		ext = ".md"
+	}
+	return filepath.Join(os.TempDir(), "al_note_"+strings.TrimSuffix(itemFileName(noteName), ".json")+ext)
+}
+
 func findSimilarNotes(projectPath, noteName string, maxDistance int) ([]string, error) {
 	notes, err := listNotes(projectPath)
 	if err != nil {
@@ -178,40 +251,106 @@ func findSimilarNotes(projectPath, noteName string, maxDistance int) ([]string,
 }
 
 func runNoteList(cmd *cobra.Command, args []string) error {
-	projectPath, err := getProjectPath()
+	projects, err := getNamedProjects(noteListAll, getProjectPath)
 	if err != nil {
 		return err
 	}
 
-	notes, err := listNotes(projectPath)
+	// Listings across projects use the global settings
+	configPath := ""
+	if !noteListAll {
+		configPath = projects[0].path
+	}
+	config := effectiveConfig(configPath)
+
+	type listedNote struct {
+		project string
+		note    Note
+	}
+
+	var all []listedNote
+	var entries []listEntry
+	for _, project := range projects {
+		notes, err := listNotes(project.path)
+		if err != nil {
+			return err
+		}
+		for _, note := range notes {
+			all = append(all, listedNote{project: project.name, note: note})
+			entries = append(entries, listEntry{
+				project:   project.name,
+				name:      note.Name,
+				createdAt: note.CreatedAt,
+				updatedAt: note.UpdatedAt,
+				size:      len(note.Content),
+				encrypted: note.Encrypted,
+				pinned:    note.Pinned,
+			})
+		}
+	}
+
+	selected, err := noteListOpts.selectEntries(entries, config.Sort)
 	if err != nil {
 		return err
 	}
+	listed := make([]listedNote, 0, len(selected))
+	for _, i := range selected {
+		listed = append(listed, all[i])
+	}
+
+	if isStructuredOutput() {
+		if noteListAll {
+			out := make([]projectNoteOutput, 0, len(listed))
+			for _, l := range listed {
+				out = append(out, projectNoteOutput{Project: l.project, noteOutput: newNoteOutput(l.note)})
+			}
+			return printOutput(out)
+		}
+		out := make([]noteOutput, 0, len(listed))
+		for _, l := range listed {
+			out = append(out, newNoteOutput(l.note))
+		}
+		return printOutput(out)
+	}
 
-	if len(notes) == 0 {
+	if len(listed) == 0 {
 		fmt.Println("No notes found.")
 		return nil
 	}
 
-	config, _ := storage.LoadConfig()
 	previewLength := config.PreviewLength
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Name\tDate\tPreview")
-	fmt.Fprintln(w, "----\t----\t-------")
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	if noteListAll {
+		fmt.Fprintln(w, "Project\tName\tDate\tPreview")
+		fmt.Fprintln(w, "-------\t----\t----\t-------")
+	} else {
+		fmt.Fprintln(w, "Name\tDate\tPreview")
+		fmt.Fprintln(w, "----\t----\t-------")
+	}
 
-	for _, note := range notes {
-		date := note.UpdatedAt.Format("2006-01-02")
-		preview := "**chiffrée**"
-		if !note.Encrypted {
+	for _, l := range listed {
+		note := l.note
+		date := note.UpdatedAt.Format(config.DateFormat)
+		preview := encryptedLabel(config)
+		if note.Binary && !note.Encrypted {
+			preview = "[binary]"
+		} else if !note.Encrypted {
 			preview = utils.TruncateString(note.Content, previewLength)
 			preview = strings.ReplaceAll(preview, "\n", " ")
 		}
+		if len(note.Attachments) > 0 {
+			preview += fmt.Sprintf("   📎 %d", len(note.Attachments))
+		}
+		if noteListAll {
+			fmt.Fprintf(w, "%s\t", l.project)
+		}
 		fmt.Fprintf(w, "%s\t%s\t%s\n", note.Name, date, preview)
 	}
 
 	w.Flush()
-	return nil
+	return utils.Page(buf.String())
 }
 
 func runNoteAdd(cmd *cobra.Command, args []string) error {
@@ -221,14 +360,52 @@ func runNoteAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	noteName := strings.TrimPrefix(args[0], "#")
+	if err := validateName("note", noteName); err != nil {
+		return err
+	}
 
 	// Check if note already exists
-	if _, err := loadNote(projectPath, noteName); err == nil {
-		return fmt.Errorf("note '%s' already exists", noteName)
+	if existing, err := loadNoteFile(projectPath, noteName); err == nil {
+		return itemExistsError("note", noteName, existing.Name)
+	}
+
+	// Templates are Markdown unless another format is asked for
+	var initial string
+	format := noteFormat
+	if noteTemplate != "" {
+		text, err := loadNoteTemplate(noteTemplate)
+		if err != nil {
+			return reportTemplateNotFound(noteTemplate, templateKindNote)
+		}
+		initial = expandTemplate(text, newTemplateVars(projectPath, noteName))
+		if format == "" {
+			format = noteFormatMarkdown
+		}
+	}
+	if format == "" {
+		format = effectiveConfig(projectPath).NoteFormat
+	}
+	if !contains(noteFormats, format) {
+		return fmt.Errorf("unknown note format '%s' (expected plain or markdown)", format)
+	}
+
+	if len(args) == 2 && args[1] != stdinArg {
+		return fmt.Errorf("unexpected argument '%s' (use '-' to read the standard input)", args[1])
+	}
+	if len(args) == 2 && noteEncrypted {
+		return errStdinPassword
+	}
+	data, fromInput, err := readNoteInput(len(args) == 2)
+	if err != nil {
+		return err
+	}
+	if fromInput && (noteBody != "" || noteTemplate != "") {
+		return fmt.Errorf("cannot combine --body or --template with the standard input or --from-file")
 	}
 
 	var content string
 	var password string
+	var binary bool
 
 	if noteEncrypted {
 		password, err = utils.ReadPassword("Enter encryption password: ")
@@ -244,17 +421,19 @@ func runNoteAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if noteBody != "" {
+	if fromInput {
+		content, binary = encodeNoteContent(data)
+	} else if noteBody != "" {
 		content = noteBody
 	} else {
 		// Create temporary file for editing
-		tmpFile := filepath.Join(os.TempDir(), "al_note_"+noteName+".txt")
-		if err := os.WriteFile(tmpFile, []byte(""), 0600); err != nil {
+		tmpFile := noteTempFile(noteName, format)
+		if err := os.WriteFile(tmpFile, []byte(initial), 0600); err != nil {
 			return err
 		}
 		defer os.Remove(tmpFile)
 
-		if err := utils.OpenEditor(tmpFile); err != nil {
+		if err := openEditor(projectPath, tmpFile); err != nil {
 			return err
 		}
 
@@ -278,6 +457,8 @@ func runNoteAdd(cmd *cobra.Command, args []string) error {
 		Name:      noteName,
 		Content:   content,
 		Encrypted: noteEncrypted,
+		Format:    format,
+		Binary:    binary,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -300,39 +481,45 @@ func runNoteGet(cmd *cobra.Command, args []string) error {
 
 	note, err := loadNote(projectPath, noteName)
 	if err != nil {
-		// Try to find similar notes
-		similar, _ := findSimilarNotes(projectPath, noteName, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Note '%s' not found. Did you mean:\n", noteName)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
-		}
-		return fmt.Errorf("note '%s' not found", noteName)
+		return reportNoteNotFound(projectPath, noteName, err)
 	}
 
-	content := note.Content
-	if note.Encrypted {
-		password, err := utils.ReadPassword("Enter decryption password: ")
-		if err != nil {
-			return err
+	content, err := decryptNoteContent(note)
+	if err != nil {
+		return err
+	}
+
+	if note.Binary && !isStructuredOutput() {
+		if noteCopy || cmd.Flags().Changed("block") {
+			return checkTextNote(note, "copied or split into blocks")
 		}
+		return writeBinaryNote(note, content)
+	}
 
-		decrypted, err := utils.Decrypt(content, password)
+	if cmd.Flags().Changed("block") {
+		block, err := selectCodeBlock(codeBlocks(content), note.Name, noteGetBlock)
 		if err != nil {
 			return err
 		}
-		content = decrypted
+		content = block.Code
 	}
 
 	if noteCopy {
-		if err := utils.CopyToClipboard(content); err != nil {
+		if err := copyToClipboard(projectPath, content); err != nil {
 			return err
 		}
+	}
+
+	if isStructuredOutput() {
+		return printOutput(noteContentOutput{noteOutput: newNoteOutput(*note), Content: content})
+	}
+
+	if noteCopy {
 		fmt.Println("✓ Note copied to clipboard")
-	} else {
+	} else if noteRaw || cmd.Flags().Changed("block") {
 		fmt.Println(content)
+	} else {
+		fmt.Println(renderNoteContent(projectPath, note, content))
 	}
 
 	return nil
@@ -348,16 +535,17 @@ func runNoteEdit(cmd *cobra.Command, args []string) error {
 
 	note, err := loadNote(projectPath, noteName)
 	if err != nil {
-		// Try to find similar notes
-		similar, _ := findSimilarNotes(projectPath, noteName, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Note '%s' not found. Did you mean:\n", noteName)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
+		return reportNoteNotFound(projectPath, noteName, err)
+	}
+	if err := checkTextNote(note, "edited"); err != nil {
+		return err
+	}
+
+	if noteFormat != "" {
+		if !contains(noteFormats, noteFormat) {
+			return fmt.Errorf("unknown note format '%s' (expected plain or markdown)", noteFormat)
 		}
-		return fmt.Errorf("note '%s' not found", noteName)
+		note.Format = noteFormat
 	}
 
 	var password string
@@ -380,13 +568,13 @@ func runNoteEdit(cmd *cobra.Command, args []string) error {
 		content = noteBody
 	} else {
 		// Create temporary file for editing
-		tmpFile := filepath.Join(os.TempDir(), "al_note_"+noteName+".txt")
+		tmpFile := noteTempFile(noteName, note.Format)
 		if err := os.WriteFile(tmpFile, []byte(content), 0600); err != nil {
 			return err
 		}
 		defer os.Remove(tmpFile)
 
-		if err := utils.OpenEditor(tmpFile); err != nil {
+		if err := openEditor(projectPath, tmpFile); err != nil {
 			return err
 		}
 
@@ -425,17 +613,9 @@ func runNoteRemove(cmd *cobra.Command, args []string) error {
 
 	noteName := strings.TrimPrefix(args[0], "#")
 
-	if _, err := loadNote(projectPath, noteName); err != nil {
-		// Try to find similar notes
-		similar, _ := findSimilarNotes(projectPath, noteName, 3)
-		if len(similar) > 0 {
-			fmt.Printf("Note '%s' not found. Did you mean:\n", noteName)
-			for _, s := range similar {
-				fmt.Printf("  - %s\n", s)
-			}
-			return nil
-		}
-		return fmt.Errorf("note '%s' not found", noteName)
+	note, err := loadNote(projectPath, noteName)
+	if err != nil {
+		return reportNoteNotFound(projectPath, noteName, err)
 	}
 
 	if !utils.AskConfirmation(fmt.Sprintf("Are you sure you want to delete note '%s'?", noteName)) {
@@ -447,6 +627,9 @@ func runNoteRemove(cmd *cobra.Command, args []string) error {
 	if err := os.Remove(notePath); err != nil {
 		return err
 	}
+	if err := removeUnusedAttachments(projectPath, note.Attachments); err != nil {
+		return err
+	}
 
 	fmt.Printf("✓ Note '%s' deleted\n", noteName)
 	return nil
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+// maxNoteSize is the largest note content read from stdin or a file, and
+// the largest note al note append may produce
+const maxNoteSize = 1 << 20
+
+// stdinArg stands for the standard input in place of a note body
+const stdinArg = "-"
+
+var noteFromFile string
+
+var noteAppendCmd = &cobra.Command{
+	Use:   "append [#name] [text...]",
+	Short: "Append a timestamped entry to a note",
+	Long: `Append a timestamped entry to an existing note. The entry is the text given
+as arguments, the standard input with '-', a file with --from-file, or
+typed in the editor.
+
+Example: al note append #log "Restarted the API"
+         kubectl get pods | al note append #log -`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteAppend,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{noteAddCmd, noteAppendCmd} {
+		c.Flags().StringVar(&noteFromFile, "from-file", "", "Read the content from a file")
+	}
+
+	noteCmd.AddCommand(noteAppendCmd)
+}
+
+// readLimited reads at most maxNoteSize bytes from r
+func readLimited(r io.Reader, source string) ([]byte, error) {
+	return readLimitedTo(r, source, maxNoteSize)
+}
+
+// readLimitedTo reads r, failing when it holds more than limit bytes
+func readLimitedTo(r io.Reader, source string, limit int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%s is larger than the %s limit", source, formatSize(limit))
+	}
+	return data, nil
+}
+
+// errStdinPassword is returned when the password of an encrypted note would
+// have to be read from a redirected standard input
+var errStdinPassword = fmt.Errorf("the password of encrypted notes is read from the standard input: use --from-file instead of '-'")
+
+// readNoteInput reads a note content from the standard input or from the
+// file given with --from-file. ok is false when neither is requested.
+func readNoteInput(fromStdin bool) (data []byte, ok bool, err error) {
+	switch {
+	case fromStdin && noteFromFile != "":
+		return nil, false, fmt.Errorf("cannot read both the standard input and --from-file")
+	case fromStdin:
+		data, err = readLimited(os.Stdin, "the standard input")
+		return data, true, err
+	case noteFromFile != "":
+		f, err := os.Open(noteFromFile)
+		if err != nil {
+			return nil, false, err
+		}
+		defer f.Close()
+		data, err = readLimited(f, noteFromFile)
+		return data, true, err
+	}
+	return nil, false, nil
+}
+
+// isBinaryContent reports whether data cannot be stored as note text
+func isBinaryContent(data []byte) bool {
+	return !utf8.Valid(data) || strings.ContainsRune(string(data), 0)
+}
+
+// encodeNoteContent returns the text stored for data: the data itself, or
+// its base64 encoding for binary data
+func encodeNoteContent(data []byte) (string, bool) {
+	if isBinaryContent(data) {
+		return base64.StdEncoding.EncodeToString(data), true
+	}
+	return string(data), false
+}
+
+// checkTextNote rejects the binary notes for the actions working on text
+func checkTextNote(note *Note, action string) error {
+	if note.Binary {
+		return fmt.Errorf("note '%s' holds binary content and cannot be %s", note.Name, action)
+	}
+	return nil
+}
+
+// writeBinaryNote writes the decoded content of a binary note to stdout,
+// unless stdout is a terminal
+func writeBinaryNote(note *Note, content string) error {
+	if utils.IsTerminal(os.Stdout) {
+		return fmt.Errorf("note '%s' holds binary content: redirect the output to a file (al note get #%s > file)", note.Name, note.Name)
+	}
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return fmt.Errorf("note '%s' has corrupted binary content: %w", note.Name, err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// appendEntry adds a timestamped entry at the end of a note content: a
+// heading in Markdown notes, a bracketed date in plain ones
+func appendEntry(content, format, entry string, now time.Time) string {
+	entry = strings.TrimRight(entry, "\n")
+	stamp := now.Format("2006-01-02 15:04")
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n")
+		if format == noteFormatMarkdown {
+			b.WriteString("\n")
+		}
+	}
+	if format == noteFormatMarkdown {
+		b.WriteString("### " + stamp + "\n\n" + entry + "\n")
+	} else {
+		b.WriteString("[" + stamp + "] " + entry + "\n")
+	}
+	return b.String()
+}
+
+func runNoteAppend(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	noteName := strings.TrimPrefix(args[0], "#")
+
+	note, err := loadNote(projectPath, noteName)
+	if err != nil {
+		return reportNoteNotFound(projectPath, noteName, err)
+	}
+	if err := checkTextNote(note, "appended to"); err != nil {
+		return err
+	}
+
+	fromStdin := len(args) == 2 && args[1] == stdinArg
+	if fromStdin && note.Encrypted {
+		return errStdinPassword
+	}
+	data, ok, err := readNoteInput(fromStdin)
+	if err != nil {
+		return err
+	}
+
+	var entry string
+	switch {
+	case ok:
+		if isBinaryContent(data) {
+			return fmt.Errorf("cannot append binary content to note '%s'", note.Name)
+		}
+		entry = string(data)
+	case len(args) > 1:
+		entry = strings.Join(args[1:], " ")
+	default:
+		tmpFile := noteTempFile(noteName, note.Format)
+		if err := os.WriteFile(tmpFile, []byte(""), 0600); err != nil {
+			return err
+		}
+		defer os.Remove(tmpFile)
+
+		if err := openEditor(projectPath, tmpFile); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(tmpFile)
+		if err != nil {
+			return err
+		}
+		entry = string(data)
+	}
+
+	if strings.TrimSpace(entry) == "" {
+		fmt.Println("Nothing to append.")
+		return nil
+	}
+
+	var password string
+	content := note.Content
+	if note.Encrypted {
+		password, err = utils.ReadPassword("Enter decryption password: ")
+		if err != nil {
+			return err
+		}
+
+		decrypted, err := utils.Decrypt(content, password)
+		if err != nil {
+			return err
+		}
+		content = decrypted
+	}
+
+	content = appendEntry(content, note.Format, entry, time.Now())
+	if len(content) > maxNoteSize {
+		return fmt.Errorf("note '%s' would be larger than the %s limit", note.Name, formatSize(maxNoteSize))
+	}
+
+	// Re-encrypt if needed
+	if note.Encrypted {
+		encrypted, err := utils.Encrypt(content, password)
+		if err != nil {
+			return err
+		}
+		content = encrypted
+	}
+
+	note.Content = content
+	note.UpdatedAt = time.Now()
+
+	if err := saveNote(projectPath, note); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Entry appended to note '%s'\n", note.Name)
+	return nil
+}
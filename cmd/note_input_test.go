@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadLimited(t *testing.T) {
+	exact := bytes.Repeat([]byte("a"), maxNoteSize)
+	if data, err := readLimited(bytes.NewReader(exact), "the standard input"); err != nil || len(data) != maxNoteSize {
+		t.Errorf("a note of exactly 1 MiB: %d bytes, %v", len(data), err)
+	}
+
+	tooLarge := append(exact, 'b')
+	_, err := readLimited(bytes.NewReader(tooLarge), "notes.md")
+	if err == nil || err.Error() != "notes.md is larger than the 1.0 MiB limit" {
+		t.Errorf("a note over 1 MiB: %v", err)
+	}
+}
+
+func TestReadNoteInput(t *testing.T) {
+	t.Cleanup(func() { noteFromFile = "" })
+
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("from file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	noteFromFile = ""
+	if _, ok, err := readNoteInput(false); ok || err != nil {
+		t.Errorf("no input requested: ok %v, err %v", ok, err)
+	}
+
+	withStdin(t, "from stdin", func() {
+		if data, ok, err := readNoteInput(true); !ok || err != nil || string(data) != "from stdin" {
+			t.Errorf("stdin: %q, %v, %v", data, ok, err)
+		}
+	})
+
+	noteFromFile = path
+	if data, ok, err := readNoteInput(false); !ok || err != nil || string(data) != "from file" {
+		t.Errorf("--from-file: %q, %v, %v", data, ok, err)
+	}
+	if _, _, err := readNoteInput(true); err == nil {
+		t.Error("expected an error for both stdin and --from-file")
+	}
+
+	noteFromFile = filepath.Join(t.TempDir(), "missing.md")
+	if _, _, err := readNoteInput(false); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestEncodeNoteContent(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		want   string
+		binary bool
+	}{
+		{data: []byte("été\n"), want: "été\n"},
+		{data: []byte{0xff, 0xfe}, want: "//4=", binary: true},
+		{data: []byte("a\x00b"), want: "YQBi", binary: true},
+	}
+
+	for _, tt := range tests {
+		got, binary := encodeNoteContent(tt.data)
+		if got != tt.want || binary != tt.binary {
+			t.Errorf("encodeNoteContent(%q) = %q, %v, want %q, %v", tt.data, got, binary, tt.want, tt.binary)
+		}
+	}
+}
+
+func TestAppendEntry(t *testing.T) {
+	now := time.Date(2026, 3, 15, 9, 5, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		entry   string
+		want    string
+	}{
+		{name: "markdown", content: "# Log\n\n", format: noteFormatMarkdown, entry: "Restarted the API\n", want: "# Log\n\n### 2026-03-15 09:05\n\nRestarted the API\n"},
+		{name: "empty markdown note", format: noteFormatMarkdown, entry: "first", want: "### 2026-03-15 09:05\n\nfirst\n"},
+		{name: "plain", content: "[2026-03-14 18:00] deployed", format: noteFormatPlain, entry: "rolled back\n\n", want: "[2026-03-14 18:00] deployed\n[2026-03-15 09:05] rolled back\n"},
+		{name: "empty plain note", format: noteFormatPlain, entry: "a\nb", want: "[2026-03-15 09:05] a\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendEntry(tt.content, tt.format, tt.entry, now); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunNoteAppend(t *testing.T) {
+	setupTestHome(t)
+	t.Cleanup(func() { noteGlobal, noteFromFile = false, "" })
+	noteGlobal = true
+
+	if err := saveNote("", &Note{Name: "log", Content: "start", Format: noteFormatPlain}); err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(t, func() {
+		if err := runNoteAppend(noteAppendCmd, []string{"#log", "restarted", "the", "API"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	note, err := loadNote("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(note.Content, "start\n[") || !strings.HasSuffix(note.Content, "] restarted the API\n") {
+		t.Errorf("content = %q", note.Content)
+	}
+
+	// The note may not grow over the limit
+	withStdin(t, strings.Repeat("x", maxNoteSize-10), func() {
+		err = runNoteAppend(noteAppendCmd, []string{"#log", "-"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "would be larger than the 1.0 MiB limit") {
+		t.Errorf("append over the limit: %v", err)
+	}
+
+	withStdin(t, "\x00\x01", func() {
+		err = runNoteAppend(noteAppendCmd, []string{"#log", "-"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot append binary content") {
+		t.Errorf("binary append: %v", err)
+	}
+
+	if err := saveNote("", &Note{Name: "secret", Content: "x", Encrypted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runNoteAppend(noteAppendCmd, []string{"#secret", "-"}); err != errStdinPassword {
+		t.Errorf("encrypted note from stdin: %v", err)
+	}
+}
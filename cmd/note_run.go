@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+type codeBlockOutput struct {
+	Index int    `json:"index"`
+	Lang  string `json:"lang"`
+	Code  string `json:"code"`
+}
+
+var (
+	noteRunAll   bool
+	noteGetBlock int
+)
+
+var noteRunCmd = &cobra.Command{
+	Use:   "run [#name] [block]",
+	Short: "List or run the code blocks of a note",
+	Long: `List the fenced code blocks of a note, or run one of them in the project
+directory. With --all, every block is run in sequence, asking for
+confirmation before each one. Blocks in languages al cannot run (yaml,
+json...) are skipped.
+
+Example: al note run #runbook
+         al note run #runbook 3
+         al note run #runbook --all`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteRun,
+}
+
+func init() {
+	noteRunCmd.Flags().BoolVarP(&noteRunAll, "all", "a", false, "Run every block, with confirmation between steps")
+	noteGetCmd.Flags().IntVar(&noteGetBlock, "block", 0, "Only get the code block with this number (see al note run)")
+
+	noteCmd.AddCommand(noteRunCmd)
+}
+
+// blockInterpreters maps the language of a code block to the command running it
+var blockInterpreters = map[string][]string{
+	"":        {"sh", "-c"},
+	"sh":      {"sh", "-c"},
+	"shell":   {"sh", "-c"},
+	"console": {"sh", "-c"},
+	"bash":    {"bash", "-c"},
+	"zsh":     {"zsh", "-c"},
+	"fish":    {"fish", "-c"},
+	"python":  {"python3", "-c"},
+	"py":      {"python3", "-c"},
+}
+
+// decryptNoteContent returns the content of a note, asking for the password
+// of encrypted notes
+func decryptNoteContent(note *Note) (string, error) {
+	if !note.Encrypted {
+		return note.Content, nil
+	}
+
+	password, err := utils.ReadPassword("Enter decryption password: ")
+	if err != nil {
+		return "", err
+	}
+	return utils.Decrypt(note.Content, password)
+}
+
+// selectCodeBlock returns the block with the given 1-based number
+func selectCodeBlock(blocks []codeBlock, noteName string, number int) (codeBlock, error) {
+	if len(blocks) == 0 {
+		return codeBlock{}, fmt.Errorf("note '%s' has no code blocks", noteName)
+	}
+	if number < 1 || number > len(blocks) {
+		return codeBlock{}, fmt.Errorf("note '%s' has %d code blocks, there is no block %d", noteName, len(blocks), number)
+	}
+	return blocks[number-1], nil
+}
+
+// blockScript returns the code to run, without the "$ " prompts of console blocks
+func blockScript(block codeBlock) string {
+	if block.Lang != "console" {
+		return block.Code
+	}
+	var lines []string
+	for _, line := range strings.Split(block.Code, "\n") {
+		if strings.HasPrefix(line, "$ ") {
+			lines = append(lines, strings.TrimPrefix(line, "$ "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runCodeBlock runs a block in dir with the terminal attached
+func runCodeBlock(block codeBlock, dir string) error {
+	interpreter, ok := blockInterpreters[block.Lang]
+	if !ok {
+		return fmt.Errorf("cannot run '%s' code blocks", block.Lang)
+	}
+
+	cmd := exec.Command(interpreter[0], append(interpreter[1:], blockScript(block))...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// printCodeBlock shows a block before it is run
+func printCodeBlock(number int, block codeBlock) {
+	renderer := markdownRenderer{color: useColor()}
+	label := block.Lang
+	if label == "" {
+		label = "sh"
+	}
+	fmt.Println(renderer.style(fmt.Sprintf("── Block %d (%s)", number, label), ansiBold))
+	for _, line := range strings.Split(block.Code, "\n") {
+		fmt.Println("  " + renderer.style(line, ansiCyan))
+	}
+}
+
+func runNoteRun(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	noteName := strings.TrimPrefix(args[0], "#")
+
+	note, err := loadNote(projectPath, noteName)
+	if err != nil {
+		return reportNoteNotFound(projectPath, noteName, err)
+	}
+
+	if err := checkTextNote(note, "run"); err != nil {
+		return err
+	}
+
+	content, err := decryptNoteContent(note)
+	if err != nil {
+		return err
+	}
+	blocks := codeBlocks(content)
+
+	// Global notes run in the current directory
+	dir := projectPath
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case len(args) == 2:
+		number, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid block number '%s'", args[1])
+		}
+		block, err := selectCodeBlock(blocks, note.Name, number)
+		if err != nil {
+			return err
+		}
+		printCodeBlock(number, block)
+		if err := runCodeBlock(block, dir); err != nil {
+			return fmt.Errorf("block %d failed: %w", number, err)
+		}
+		return nil
+
+	case noteRunAll:
+		if len(blocks) == 0 {
+			return fmt.Errorf("note '%s' has no code blocks", note.Name)
+		}
+		run, skipped := 0, 0
+		for i, block := range blocks {
+			// Configuration snippets (yaml, json...) are not commands
+			if _, ok := blockInterpreters[block.Lang]; !ok {
+				fmt.Printf("Skipping block %d/%d (%s)\n", i+1, len(blocks), block.Lang)
+				skipped++
+				continue
+			}
+			printCodeBlock(i+1, block)
+			if !utils.AskConfirmation(fmt.Sprintf("Run block %d/%d?", i+1, len(blocks))) {
+				fmt.Println("Stopped.")
+				return nil
+			}
+			if err := runCodeBlock(block, dir); err != nil {
+				return fmt.Errorf("block %d failed: %w", i+1, err)
+			}
+			run++
+		}
+		fmt.Printf("✓ %d blocks run", run)
+		if skipped > 0 {
+			fmt.Printf(", %d skipped (no interpreter)", skipped)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	if isStructuredOutput() {
+		out := make([]codeBlockOutput, 0, len(blocks))
+		for i, block := range blocks {
+			out = append(out, codeBlockOutput{Index: i + 1, Lang: block.Lang, Code: block.Code})
+		}
+		return printOutput(out)
+	}
+
+	if len(blocks) == 0 {
+		fmt.Printf("Note '%s' has no code blocks.\n", note.Name)
+		return nil
+	}
+
+	previewLength := effectiveConfig(projectPath).PreviewLength
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "#\tLang\tCode")
+	fmt.Fprintln(w, "-\t----\t----")
+	for i, block := range blocks {
+		preview := utils.TruncateString(strings.ReplaceAll(block.Code, "\n", " ; "), previewLength)
+		fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, block.Lang, preview)
+	}
+
+	w.Flush()
+	return nil
+}
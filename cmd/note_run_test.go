@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlockScript(t *testing.T) {
+	tests := []struct {
+		block codeBlock
+		want  string
+	}{
+		{block: codeBlock{Lang: "sh", Code: "$ make\necho $HOME"}, want: "$ make\necho $HOME"},
+		{block: codeBlock{Lang: "console", Code: "$ make build\nok: built\n$ ./deploy.sh\n$notacommand"}, want: "make build\n./deploy.sh"},
+		{block: codeBlock{Lang: "console", Code: "output only"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := blockScript(tt.block); got != tt.want {
+			t.Errorf("blockScript(%+v) = %q, want %q", tt.block, got, tt.want)
+		}
+	}
+}
+
+func TestSelectCodeBlock(t *testing.T) {
+	blocks := []codeBlock{{Lang: "sh", Code: "one"}, {Lang: "bash", Code: "two"}}
+
+	if block, err := selectCodeBlock(blocks, "runbook", 2); err != nil || block.Code != "two" {
+		t.Errorf("selectCodeBlock(2) = %+v, %v", block, err)
+	}
+
+	tests := []struct {
+		blocks  []codeBlock
+		number  int
+		wantErr string
+	}{
+		{blocks: nil, number: 1, wantErr: "note 'runbook' has no code blocks"},
+		{blocks: blocks, number: 0, wantErr: "note 'runbook' has 2 code blocks, there is no block 0"},
+		{blocks: blocks, number: 3, wantErr: "there is no block 3"},
+		{blocks: blocks, number: -1, wantErr: "there is no block -1"},
+	}
+	for _, tt := range tests {
+		if _, err := selectCodeBlock(tt.blocks, "runbook", tt.number); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("selectCodeBlock(%d) = %v, want %q", tt.number, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunCodeBlock(t *testing.T) {
+	dir := t.TempDir()
+
+	out := captureStdout(t, func() {
+		if err := runCodeBlock(codeBlock{Lang: "console", Code: "$ pwd\n/somewhere\n$ echo done"}, dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	resolved, _ := filepath.EvalSymlinks(dir)
+	if out != resolved+"\ndone\n" && out != dir+"\ndone\n" {
+		t.Errorf("got %q, want the block run in %s", out, dir)
+	}
+
+	if err := runCodeBlock(codeBlock{Lang: "sh", Code: "exit 3"}, dir); err == nil {
+		t.Error("expected an error for a failing block")
+	}
+	if err := runCodeBlock(codeBlock{Lang: "yaml", Code: "a: 1"}, dir); err == nil || !strings.Contains(err.Error(), "cannot run 'yaml' code blocks") {
+		t.Errorf("yaml block: %v", err)
+	}
+}
+
+func TestRunNoteRunAll(t *testing.T) {
+	setupTestHome(t)
+	t.Cleanup(func() { noteGlobal, noteRunAll = false, false })
+	noteGlobal, noteRunAll = true, true
+	chdir(t, t.TempDir())
+
+	content := "```yaml\nkey: value\n```\n```sh\necho ran > result\n```\n"
+	if err := saveNote("", &Note{Name: "runbook", Content: content}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out string
+	withStdin(t, "y\n", func() {
+		out = captureStdout(t, func() {
+			if err := runNoteRun(noteRunCmd, []string{"#runbook"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+	if !strings.Contains(out, "Skipping block 1/2 (yaml)") || !strings.Contains(out, "✓ 1 blocks run, 1 skipped (no interpreter)") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if data, err := os.ReadFile("result"); err != nil || string(data) != "ran\n" {
+		t.Errorf("block not run in the current directory: %q, %v", data, err)
+	}
+
+	// Declining stops before the block runs
+	os.Remove("result")
+	withStdin(t, "n\n", func() {
+		out = captureStdout(t, func() {
+			if err := runNoteRun(noteRunCmd, []string{"#runbook"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+	if !strings.Contains(out, "Stopped.") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if _, err := os.Stat("result"); !os.IsNotExist(err) {
+		t.Error("declined block was run")
+	}
+}
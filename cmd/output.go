@@ -0,0 +1,541 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alex/al/storage"
+)
+
+// Structured output formats accepted by --output
+var outputFormats = []string{"json", "yaml", "tsv"}
+
+var outputFormat string
+
+// shellOutputAnnotation marks the commands whose output is evaluated by the
+// shell: they always print text, and their errors go to stderr
+const shellOutputAnnotation = "shell_output"
+
+type projectOutput struct {
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	Shortcuts   []string   `json:"shortcuts"`
+	Client      string     `json:"client"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	Status      string     `json:"status"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+type noteOutput struct {
+	Name         string    `json:"name"`
+	Format       string    `json:"format"`
+	Encrypted    bool      `json:"encrypted"`
+	Binary       bool      `json:"binary"`
+	Attachments  int       `json:"attachments"`
+	Pinned       bool      `json:"pinned"`
+	PinnedBlocks []int     `json:"pinned_blocks"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// noteContentOutput holds the content of a note, base64 encoded for binary
+// notes
+type noteContentOutput struct {
+	noteOutput
+	Content string `json:"content"`
+}
+
+// projectNoteOutput is a note listed with --all-projects
+type projectNoteOutput struct {
+	Project string `json:"project"`
+	noteOutput
+}
+
+type linkOutput struct {
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	Keywords    []string   `json:"keywords"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Favicon     string     `json:"favicon"`
+	Check       *LinkCheck `json:"check"`
+	Pinned      bool       `json:"pinned"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// projectLinkOutput is a link listed with --all-projects
+type projectLinkOutput struct {
+	Project string `json:"project"`
+	linkOutput
+}
+
+type envOutput struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	Encrypted bool      `json:"encrypted"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type errorOutput struct {
+	Error       string   `json:"error"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// notFoundError is returned in structured output mode when a name does not
+// match, so the suggestions end up in the error object
+type notFoundError struct {
+	kind        string
+	name        string
+	suggestions []string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s '%s' not found", e.kind, e.name)
+}
+
+// reportedError is a failure the command output already describes, such as
+// the dangling references listed by al note check: it only sets the exit
+// status
+type reportedError struct {
+	msg string
+}
+
+func (e *reportedError) Error() string {
+	return e.msg
+}
+
+func newProjectOutput(name string, project storage.Project) projectOutput {
+	shortcuts := project.Shortcuts
+	if shortcuts == nil {
+		shortcuts = []string{}
+	}
+	tags := project.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	// Registries written before the pointer stored a zero date
+	createdAt := project.CreatedAt
+	if createdAt != nil && createdAt.IsZero() {
+		createdAt = nil
+	}
+	return projectOutput{
+		Name:        name,
+		Path:        project.Path,
+		Shortcuts:   shortcuts,
+		Client:      project.Client,
+		Description: project.Description,
+		Tags:        tags,
+		Status:      project.ProjectStatus(),
+		CreatedAt:   createdAt,
+	}
+}
+
+func newNoteOutput(note Note) noteOutput {
+	return noteOutput{
+		Name:         note.Name,
+		Format:       note.Format,
+		Encrypted:    note.Encrypted,
+		Binary:       note.Binary,
+		Attachments:  len(note.Attachments),
+		Pinned:       note.Pinned,
+		PinnedBlocks: pinnedBlockNumbers(note),
+		CreatedAt:    note.CreatedAt,
+		UpdatedAt:    note.UpdatedAt,
+	}
+}
+
+func newLinkOutput(link Link) linkOutput {
+	keywords := link.Keywords
+	if keywords == nil {
+		keywords = []string{}
+	}
+	return linkOutput{
+		Name:        link.Name,
+		URL:         link.URL,
+		Keywords:    keywords,
+		Title:       link.Title,
+		Description: link.Description,
+		Favicon:     link.Favicon,
+		Check:       link.Check,
+		Pinned:      link.Pinned,
+		CreatedAt:   link.CreatedAt,
+		UpdatedAt:   link.UpdatedAt,
+	}
+}
+
+// isStructuredOutput reports whether a machine-readable format was requested
+func isStructuredOutput() bool {
+	for _, f := range outputFormats {
+		if outputFormat == f {
+			return true
+		}
+	}
+	return false
+}
+
+func validateOutputFormat() error {
+	if outputFormat == "" || isStructuredOutput() {
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s' (expected %s)", outputFormat, strings.Join(outputFormats, ", "))
+}
+
+// reportNotFound handles an unknown name. In text mode similar names are
+// printed as suggestions; in structured mode they are returned in the error.
+func reportNotFound(kind, name string, similar []string) error {
+	if isStructuredOutput() {
+		return &notFoundError{kind: kind, name: name, suggestions: similar}
+	}
+
+	if len(similar) > 0 {
+		fmt.Printf("%s '%s' not found. Did you mean:\n", capitalize(kind), name)
+		for _, s := range similar {
+			fmt.Printf("  - %s\n", s)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%s '%s' not found", kind, name)
+}
+
+// PrintError reports a command error, as an error object when a structured
+// output format was requested
+func PrintError(err error) {
+	if _, ok := err.(*reportedError); ok {
+		return
+	}
+	if !isStructuredOutput() {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	out := errorOutput{Error: err.Error(), Suggestions: []string{}}
+	if nf, ok := err.(*notFoundError); ok && nf.suggestions != nil {
+		out.Suggestions = nf.suggestions
+	}
+	printOutput(out)
+}
+
+// printOutput writes v (a record or a slice of records) to stdout in the
+// requested structured format
+func printOutput(v interface{}) error {
+	return writeOutput(os.Stdout, outputFormat, v)
+}
+
+func writeOutput(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		lines := yamlLines(reflect.ValueOf(v))
+		_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+		return err
+	case "tsv":
+		return writeTSV(w, reflect.ValueOf(v))
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+}
+
+// field is a struct field flattened through embedded structs
+type field struct {
+	name  string
+	index []int
+}
+
+func structFields(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for _, sub := range structFields(f.Type) {
+				sub.index = append([]int{i}, sub.index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields = append(fields, field{name: name, index: []int{i}})
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func isScalar(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil() || isScalar(v.Elem())
+	case reflect.Struct:
+		return v.Type() == timeType
+	case reflect.Slice, reflect.Map:
+		return false
+	}
+	return true
+}
+
+func scalarString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return scalarString(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_./][A-Za-z0-9_./@+-]*$`)
+
+func yamlScalar(v reflect.Value) string {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "null"
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	s := scalarString(v)
+	if v.Kind() != reflect.String && v.Type() != timeType {
+		return s
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "y", "n", "on", "off", "null", "~", ".inf", ".nan":
+		return strconv.Quote(s)
+	}
+	if yamlPlain.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlLines renders a value as YAML block lines
+func yamlLines(v reflect.Value) []string {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if isScalar(v) {
+		return []string{yamlScalar(v)}
+	}
+
+	var lines []string
+	appendEntry := func(key string, child reflect.Value) {
+		for (child.Kind() == reflect.Ptr || child.Kind() == reflect.Interface) && !child.IsNil() {
+			child = child.Elem()
+		}
+		switch {
+		case isScalar(child):
+			lines = append(lines, key+": "+yamlScalar(child))
+		case child.Kind() == reflect.Slice && child.Len() == 0:
+			lines = append(lines, key+": []")
+		case child.Kind() == reflect.Map && child.Len() == 0:
+			lines = append(lines, key+": {}")
+		default:
+			lines = append(lines, key+":")
+			for _, l := range yamlLines(child) {
+				lines = append(lines, "  "+l)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			appendEntry(f.name, v.FieldByIndex(f.index))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return scalarString(keys[i]) < scalarString(keys[j])
+		})
+		for _, k := range keys {
+			appendEntry(yamlScalar(k), v.MapIndex(k))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return []string{"[]"}
+		}
+		for i := 0; i < v.Len(); i++ {
+			for j, l := range yamlLines(v.Index(i)) {
+				if j == 0 {
+					lines = append(lines, "- "+l)
+				} else {
+					lines = append(lines, "  "+l)
+				}
+			}
+		}
+	}
+
+	return lines
+}
+
+// tsvColumn is a leaf column reached through nested structs
+type tsvColumn struct {
+	name  string
+	index [][]int
+}
+
+func tsvColumns(t reflect.Type, prefix string, path [][]int) []tsvColumn {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var columns []tsvColumn
+	for _, f := range structFields(t) {
+		ft := t.FieldByIndex(f.index).Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fieldPath := append(append([][]int{}, path...), f.index)
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			columns = append(columns, tsvColumns(ft, prefix+f.name+".", fieldPath)...)
+			continue
+		}
+		columns = append(columns, tsvColumn{name: prefix + f.name, index: fieldPath})
+	}
+	return columns
+}
+
+func tsvCell(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	var s string
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = tsvCell(v.Index(i))
+		}
+		s = strings.Join(parts, ",")
+	} else {
+		s = scalarString(v)
+	}
+
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\t", `\t`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	s = strings.ReplaceAll(s, "\r", `\r`)
+	return s
+}
+
+// writeTSV writes a record or a slice of records as a header line followed
+// by one tab-separated line per record
+func writeTSV(w io.Writer, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	var rows []reflect.Value
+	t := v.Type()
+	if v.Kind() == reflect.Slice {
+		t = t.Elem()
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, v.Index(i))
+		}
+	} else {
+		rows = append(rows, v)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(w, tsvCell(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	columns := tsvColumns(t, "", nil)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(header, "\t")); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cell := row
+			for _, index := range c.index {
+				for cell.Kind() == reflect.Ptr && !cell.IsNil() {
+					cell = cell.Elem()
+				}
+				if cell.Kind() == reflect.Ptr {
+					cell = reflect.Value{}
+					break
+				}
+				cell = cell.FieldByIndex(index)
+			}
+			cells[i] = tsvCell(cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+type testInner struct {
+	Status int    `json:"status"`
+	URL    string `json:"url,omitempty"`
+}
+
+type testBase struct {
+	Name string `json:"name"`
+}
+
+type testRecord struct {
+	testBase
+	Text    string     `json:"text"`
+	Tags    []string   `json:"tags"`
+	Check   *testInner `json:"check"`
+	Inner   testInner  `json:"inner"`
+	When    *time.Time `json:"when"`
+	Skipped string     `json:"-"`
+	hidden  string
+}
+
+func writeTestOutput(t *testing.T, format string, v interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, format, v); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestWriteOutputYAML(t *testing.T) {
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "nested structs",
+			v: testRecord{
+				testBase: testBase{Name: "deploy"},
+				Text:     "plain",
+				Tags:     []string{"go", "a b"},
+				Check:    &testInner{Status: 200, URL: "https://example.com"},
+				Inner:    testInner{Status: 404},
+				When:     &when,
+			},
+			want: `name: deploy
+text: plain
+tags:
+  - go
+  - "a b"
+check:
+  status: 200
+  url: "https://example.com"
+inner:
+  status: 404
+  url: ""
+when: "2026-01-02T03:04:05Z"
+`,
+		},
+		{
+			name: "nil pointers and empty slices",
+			v:    testRecord{testBase: testBase{Name: "note"}, Tags: []string{}},
+			want: `name: note
+text: ""
+tags: []
+check: null
+inner:
+  status: 0
+  url: ""
+when: null
+`,
+		},
+		{
+			name: "slice of records",
+			v:    []testInner{{Status: 1}, {Status: 2, URL: "u"}},
+			want: `- status: 1
+  url: ""
+- status: 2
+  url: u
+`,
+		},
+		{
+			name: "empty slice",
+			v:    []testInner{},
+			want: "[]\n",
+		},
+		{
+			name: "map",
+			v:    map[string]int{"b": 2, "a": 1, "null": 3},
+			want: `a: 1
+b: 2
+"null": 3
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writeTestOutput(t, "yaml", tt.v); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLStringQuoting(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "deploy", want: `deploy`},
+		{in: "docs/guide.md", want: `docs/guide.md`},
+		{in: "", want: `""`},
+		{in: "key: value", want: `"key: value"`},
+		{in: "#comment", want: `"#comment"`},
+		{in: "a #b", want: `"a #b"`},
+		{in: "  leading", want: `"  leading"`},
+		{in: "trailing ", want: `"trailing "`},
+		{in: "line 1\nline 2", want: `"line 1\nline 2"`},
+		{in: "tab\there", want: `"tab\there"`},
+		{in: `say "hi"`, want: `"say \"hi\""`},
+		{in: "- item", want: `"- item"`},
+		{in: "123", want: `"123"`},
+		{in: "1.5", want: `"1.5"`},
+		{in: "true", want: `"true"`},
+		{in: "No", want: `"No"`},
+		{in: "y", want: `"y"`},
+		{in: "null", want: `"null"`},
+		{in: "~", want: `"~"`},
+		{in: ".inf", want: `".inf"`},
+		{in: "*alias", want: `"*alias"`},
+		{in: "&anchor", want: `"&anchor"`},
+		{in: "{a}", want: `"{a}"`},
+		{in: "été", want: `"été"`},
+	}
+
+	for _, tt := range tests {
+		got := writeTestOutput(t, "yaml", tt.in)
+		if got != tt.want+"\n" {
+			t.Errorf("yaml(%q) = %s, want %s", tt.in, strings.TrimSpace(got), tt.want)
+		}
+	}
+}
+
+func TestWriteOutputTSV(t *testing.T) {
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []testRecord{
+		{
+			testBase: testBase{Name: "a\tb"},
+			Text:     "line 1\nline 2\r\n",
+			Tags:     []string{"go", "docker"},
+			Check:    &testInner{Status: 301, URL: `C:\path`},
+			When:     &when,
+		},
+		{testBase: testBase{Name: "empty"}},
+	}
+
+	want := "name\ttext\ttags\tcheck.status\tcheck.url\tinner.status\tinner.url\twhen\n" +
+		"a\\tb\tline 1\\nline 2\\r\\n\tgo,docker\t301\tC:\\\\path\t0\t\t2026-01-02T03:04:05Z\n" +
+		"empty\t\t\t\t\t0\t\t\n"
+	if got := writeTestOutput(t, "tsv", records); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	// A single record gets the same header
+	got := writeTestOutput(t, "tsv", records[1])
+	if !strings.HasPrefix(got, "name\ttext\t") || strings.Count(got, "\n") != 2 {
+		t.Errorf("single record: %q", got)
+	}
+
+	// An empty slice still prints the header
+	if got := writeTestOutput(t, "tsv", []testInner{}); got != "status\turl\n" {
+		t.Errorf("empty slice: %q", got)
+	}
+
+	// Slices of scalars print one value per line
+	if got := writeTestOutput(t, "tsv", []string{"a", "b\tc"}); got != "a\nb\\tc\n" {
+		t.Errorf("scalars: %q", got)
+	}
+}
+
+func TestWriteOutputJSON(t *testing.T) {
+	got := writeTestOutput(t, "json", testRecord{testBase: testBase{Name: "n"}, Tags: []string{}})
+	for _, want := range []string{`"name": "n"`, `"tags": []`, `"check": null`, `"when": null`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in %s", want, got)
+		}
+	}
+	if strings.Contains(got, "Skipped") || strings.Contains(got, "hidden") {
+		t.Errorf("unexported or skipped field in %s", got)
+	}
+
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, "xml", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPrintError(t *testing.T) {
+	t.Cleanup(func() { outputFormat = "" })
+
+	tests := []struct {
+		name   string
+		format string
+		err    error
+		want   string
+	}{
+		{
+			name:   "text goes to stderr",
+			format: "",
+			err:    errors.New("boom"),
+			want:   "",
+		},
+		{
+			name:   "json error",
+			format: "json",
+			err:    errors.New("boom"),
+			want:   "{\n  \"error\": \"boom\",\n  \"suggestions\": []\n}\n",
+		},
+		{
+			name:   "json not found with suggestions",
+			format: "json",
+			err:    &notFoundError{kind: "note", name: "deplyo", suggestions: []string{"deploy"}},
+			want:   "{\n  \"error\": \"note 'deplyo' not found\",\n  \"suggestions\": [\n    \"deploy\"\n  ]\n}\n",
+		},
+		{
+			name:   "yaml not found without suggestions",
+			format: "yaml",
+			err:    &notFoundError{kind: "link", name: "x"},
+			want:   "error: \"link 'x' not found\"\nsuggestions: []\n",
+		},
+		{
+			name:   "reported errors print nothing",
+			format: "json",
+			err:    &reportedError{msg: "2 dangling references"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFormat = tt.format
+			got := captureStdout(t, func() { PrintError(tt.err) })
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportNotFound(t *testing.T) {
+	t.Cleanup(func() { outputFormat = "" })
+
+	outputFormat = "json"
+	err := reportNotFound("note", "deplyo", []string{"deploy"})
+	var nf *notFoundError
+	if !errors.As(err, &nf) || len(nf.suggestions) != 1 {
+		t.Errorf("structured mode: got %v", err)
+	}
+
+	outputFormat = ""
+	var got error
+	out := captureStdout(t, func() { got = reportNotFound("note", "deplyo", []string{"deploy"}) })
+	if got != nil || !strings.Contains(out, "Did you mean:\n  - deploy") {
+		t.Errorf("text mode with suggestions: err %v, output %q", got, out)
+	}
+	if err := reportNotFound("note", "zzz", nil); err == nil || err.Error() != "note 'zzz' not found" {
+		t.Errorf("text mode without suggestions: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// notePinBlock is the code block pinned as a command with --block
+var notePinBlock int
+
+var notePinCmd = &cobra.Command{
+	Use:   "pin [#name]",
+	Short: "Pin a note or one of its commands to the project dashboard",
+	Long: `Pin a note so that it is shown by al status. al note list --pinned only lists
+the pinned notes. With --block, a code block of the note is pinned as a
+command instead (see al note run), which al status shows with its code. The
+command follows its block when blocks are added or removed above it, and is
+unpinned when an edit changes the code of the block.
+
+Example: al note pin #runbook
+         al note pin #runbook --block 2`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNotePin(args, true)
+	},
+}
+
+var noteUnpinCmd = &cobra.Command{
+	Use:               "unpin [#name]",
+	Short:             "Unpin a note or one of its commands",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNotePin(args, false)
+	},
+}
+
+var linkPinCmd = &cobra.Command{
+	Use:   "pin [#name]",
+	Short: "Pin a link to the project dashboard",
+	Long: `Pin a link so that it is shown by al status. al link list --pinned only lists
+the pinned links.
+
+Example: al link pin #grafana`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLinkNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLinkPin(args, true)
+	},
+}
+
+var linkUnpinCmd = &cobra.Command{
+	Use:               "unpin [#name]",
+	Short:             "Unpin a link",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLinkNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLinkPin(args, false)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{notePinCmd, noteUnpinCmd} {
+		c.Flags().IntVar(&notePinBlock, "block", 0, "Pin the code block with this number as a command (see al note run)")
+	}
+
+	noteCmd.AddCommand(notePinCmd)
+	noteCmd.AddCommand(noteUnpinCmd)
+	linkCmd.AddCommand(linkPinCmd)
+	linkCmd.AddCommand(linkUnpinCmd)
+}
+
+// pinAction names the action in messages
+func pinAction(pinned bool) string {
+	if pinned {
+		return "pinned"
+	}
+	return "unpinned"
+}
+
+func runNotePin(args []string, pinned bool) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	if notePinBlock != 0 {
+		return pinNoteBlock(projectPath, note, notePinBlock, pinned)
+	}
+
+	if note.Pinned == pinned {
+		fmt.Printf("Note '%s' is already %s.\n", note.Name, pinAction(pinned))
+		return nil
+	}
+
+	// Pinning is not an edit of the note, its dates are kept
+	note.Pinned = pinned
+	if err := saveNote(projectPath, note); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Note '%s' %s\n", note.Name, pinAction(pinned))
+	return nil
+}
+
+// pinNoteBlock pins or unpins a code block of a note as a command
+func pinNoteBlock(projectPath string, note *Note, number int, pinned bool) error {
+	if err := checkTextNote(note, pinAction(pinned)); err != nil {
+		return err
+	}
+	// al status shows the pinned commands without asking for a password
+	if note.Encrypted {
+		return fmt.Errorf("the code blocks of encrypted notes cannot be pinned")
+	}
+	block, err := selectCodeBlock(codeBlocks(note.Content), note.Name, number)
+	if err != nil {
+		return err
+	}
+	if pinned {
+		if _, ok := blockInterpreters[block.Lang]; !ok {
+			return fmt.Errorf("block %d is a '%s' block, which al note run cannot run", number, block.Lang)
+		}
+	}
+
+	id := blockID(block)
+	index := -1
+	for i, pinnedID := range note.PinnedBlocks {
+		if pinnedID == id {
+			index = i
+		}
+	}
+
+	if (index >= 0) == pinned {
+		fmt.Printf("Block %d of note '%s' is already %s.\n", number, note.Name, pinAction(pinned))
+		return nil
+	}
+
+	if pinned {
+		note.PinnedBlocks = append(note.PinnedBlocks, id)
+	} else {
+		note.PinnedBlocks = append(note.PinnedBlocks[:index], note.PinnedBlocks[index+1:]...)
+	}
+	if err := saveNote(projectPath, note); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Block %d of note '%s' %s\n", number, note.Name, pinAction(pinned))
+	return nil
+}
+
+// blockID identifies a code block by its content, so that a pin follows the
+// block when the blocks above it change
+func blockID(block codeBlock) string {
+	sum := sha256.Sum256([]byte(block.Lang + "\n" + block.Code))
+	return hex.EncodeToString(sum[:8])
+}
+
+// pinnedBlockNumbers returns the numbers of the pinned code blocks of note,
+// in the order of the note
+func pinnedBlockNumbers(note Note) []int {
+	numbers := []int{}
+	if len(note.PinnedBlocks) == 0 || note.Encrypted || note.Binary {
+		return numbers
+	}
+	pinned := make(map[string]bool)
+	for _, id := range note.PinnedBlocks {
+		pinned[id] = true
+	}
+	for i, block := range codeBlocks(note.Content) {
+		if pinned[blockID(block)] {
+			numbers = append(numbers, i+1)
+		}
+	}
+	return numbers
+}
+
+// unpinChangedBlocks drops the pins of the code blocks that an edit of note
+// changed or removed, with a warning on stderr
+func unpinChangedBlocks(note *Note) {
+	if len(note.PinnedBlocks) == 0 || note.Encrypted || note.Binary {
+		return
+	}
+	ids := make(map[string]bool)
+	for _, block := range codeBlocks(note.Content) {
+		ids[blockID(block)] = true
+	}
+	var kept []string
+	for _, id := range note.PinnedBlocks {
+		if ids[id] {
+			kept = append(kept, id)
+		}
+	}
+	if dropped := len(note.PinnedBlocks) - len(kept); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d pinned command(s) of note '%s' changed and were unpinned\n", dropped, note.Name)
+	}
+	note.PinnedBlocks = kept
+}
+
+func runLinkPin(args []string, pinned bool) error {
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	linkName := strings.TrimPrefix(args[0], "#")
+
+	link, err := loadLink(projectPath, linkName)
+	if err != nil {
+		return reportLinkNotFound(projectPath, linkName, err)
+	}
+
+	if link.Pinned == pinned {
+		fmt.Printf("Link '%s' is already %s.\n", link.Name, pinAction(pinned))
+		return nil
+	}
+
+	// Pinning is not an edit of the link, its dates are kept
+	link.Pinned = pinned
+	if err := saveLink(projectPath, link); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Link '%s' %s\n", link.Name, pinAction(pinned))
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPinNoteBlockFollowsTheBlock(t *testing.T) {
+	setupTestHome(t)
+	note := &Note{Name: "runbook", Content: "```sh\necho build\n```\n```sh\necho deploy\n```"}
+	if err := saveNote("", note); err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(t, func() {
+		if err := pinNoteBlock("", note, 2, true); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// A block added above moves the pinned command to block 3
+	note.Content = "```sh\necho setup\n```\n" + note.Content
+	if err := saveNote("", note); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadNote("", "runbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pinnedBlockNumbers(*saved); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("pinned blocks = %v, want [3]", got)
+	}
+
+	// Changing the code of the block unpins it
+	saved.Content = "```sh\necho setup\n```\n```sh\necho build\n```\n```sh\necho deploy --force\n```"
+	if err := saveNote("", saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.PinnedBlocks) != 0 {
+		t.Errorf("pins of a changed block kept: %v", saved.PinnedBlocks)
+	}
+	if got := pinnedBlockNumbers(*saved); len(got) != 0 {
+		t.Errorf("pinned blocks = %v, want none", got)
+	}
+}
+
+func TestPinNoteBlockErrors(t *testing.T) {
+	setupTestHome(t)
+	note := &Note{Name: "runbook", Content: "```yaml\na: 1\n```\n```sh\nls\n```"}
+
+	if err := pinNoteBlock("", note, 1, true); err == nil {
+		t.Error("expected an error for a block al note run cannot run")
+	}
+	if err := pinNoteBlock("", note, 3, true); err == nil {
+		t.Error("expected an error for a missing block")
+	}
+	encrypted := &Note{Name: "secret", Content: "ciphertext", Encrypted: true}
+	if err := pinNoteBlock("", encrypted, 1, true); err == nil {
+		t.Error("expected an error for an encrypted note")
+	}
+
+	out := captureStdout(t, func() {
+		if err := pinNoteBlock("", note, 2, false); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "Block 2 of note 'runbook' is already unpinned.\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+// projectKeys are the metadata set by al project set
+var projectKeys = []string{"client", "description", "tags", "status"}
+
+var (
+	projectTarget     string
+	projectListTag    string
+	projectListClient string
+	projectListStatus string
+	projectListAll    bool
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project [action]",
+	Short: "List projects and manage their metadata",
+	Long: `List the registered projects and manage their metadata: client, description,
+tags and status (active, paused or archived). Actions: list, set
+
+Example: al project list --client acme
+         al project set client acme
+         al project set status archived -t old-site`,
+	Args: cobra.NoArgs,
+	RunE: runProjectList,
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	Long: `List the registered projects. Archived projects are only listed with --all
+or --status archived.
+
+Example: al project list --tag symfony
+         al project list --status paused`,
+	Args: cobra.NoArgs,
+	RunE: runProjectList,
+}
+
+var projectSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a metadata of the project",
+	Long: `Set a metadata of the current project, or of the project given with -t.
+Keys: client, description, tags (comma separated) and status (active, paused
+or archived). An empty value clears the metadata.
+
+Example: al project set client acme
+         al project set tags "symfony, docker"
+         al project set description ""`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeProjectSet,
+	RunE:              runProjectSet,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{projectCmd, projectListCmd} {
+		c.Flags().StringVar(&projectListTag, "tag", "", "Only list the projects with this tag")
+		c.Flags().StringVar(&projectListClient, "client", "", "Only list the projects of this client")
+		c.Flags().StringVar(&projectListStatus, "status", "", "Only list the projects with this status (active, paused, archived)")
+		c.RegisterFlagCompletionFunc("status", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completionCandidates(storage.ProjectStatuses, toComplete), cobra.ShellCompDirectiveNoFileComp
+		})
+		c.Flags().BoolVarP(&projectListAll, "all", "a", false, "Also list archived projects")
+	}
+	projectSetCmd.Flags().StringVarP(&projectTarget, "target", "t", "", "Target project")
+	projectSetCmd.RegisterFlagCompletionFunc("target", completeProjects)
+
+	projectCmd.AddCommand(projectListCmd)
+	projectCmd.AddCommand(projectSetCmd)
+}
+
+// parseTags splits a comma separated list of tags, dropping duplicates
+func parseTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// completeProjectSet completes the keys of al project set, then the statuses
+func completeProjectSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return completionCandidates(projectKeys, toComplete), cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && args[0] == "status":
+		return completionCandidates(storage.ProjectStatuses, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func runProjectList(cmd *cobra.Command, args []string) error {
+	if projectListStatus != "" && !contains(storage.ProjectStatuses, projectListStatus) {
+		return fmt.Errorf("unknown status '%s' (expected %s)", projectListStatus, strings.Join(storage.ProjectStatuses, ", "))
+	}
+
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name, project := range projects {
+		switch {
+		case projectListStatus != "" && project.ProjectStatus() != projectListStatus:
+			continue
+		case projectListStatus == "" && !projectListAll && project.Archived():
+			continue
+		case projectListClient != "" && !strings.EqualFold(project.Client, projectListClient):
+			continue
+		case projectListTag != "" && !containsFold(project.Tags, projectListTag):
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if isStructuredOutput() {
+		out := make([]projectOutput, 0, len(names))
+		for _, name := range names {
+			out = append(out, newProjectOutput(name, projects[name]))
+		}
+		return printOutput(out)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No projects found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tClient\tStatus\tTags\tPath")
+	fmt.Fprintln(w, "----\t------\t------\t----\t----")
+	for _, name := range names {
+		project := projects[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, project.Client, project.ProjectStatus(), strings.Join(project.Tags, ", "), project.Path)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runProjectSet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	value := strings.TrimSpace(strings.Join(args[1:], " "))
+
+	if !contains(projectKeys, key) {
+		return fmt.Errorf("unknown project key '%s' (expected %s)", key, strings.Join(projectKeys, ", "))
+	}
+
+	projectPath, err := resolveProjectPath(projectTarget)
+	if err != nil {
+		return err
+	}
+
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return err
+	}
+	name, _, err := storage.FindProjectByPath(projectPath)
+	if err != nil {
+		return err
+	}
+	project := projects[name]
+
+	switch key {
+	case "client":
+		// Use the registered spelling so the client notes are found
+		if value != "" {
+			clientName, _, err := findClientArg(value)
+			if clientName == "" {
+				return err
+			}
+			value = clientName
+		}
+		project.Client = value
+	case "description":
+		project.Description = value
+	case "tags":
+		project.Tags = parseTags(value)
+	case "status":
+		if value == "" {
+			value = storage.ProjectActive
+		}
+		if !contains(storage.ProjectStatuses, value) {
+			return fmt.Errorf("unknown status '%s' (expected %s)", value, strings.Join(storage.ProjectStatuses, ", "))
+		}
+		project.Status = value
+	}
+
+	projects[name] = project
+	if err := storage.SaveProjects(projects); err != nil {
+		return err
+	}
+
+	if key == "tags" {
+		value = strings.Join(project.Tags, ", ")
+	}
+
+	if value == "" {
+		fmt.Printf("✓ %s of project '%s' cleared\n", capitalize(key), name)
+	} else {
+		fmt.Printf("✓ %s of project '%s' set to '%s'\n", capitalize(key), name, value)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alex/al/storage"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "symfony, docker", want: []string{"symfony", "docker"}},
+		{value: " a ,, b ,", want: []string{"a", "b"}},
+		{value: "Docker,docker,DOCKER", want: []string{"Docker"}},
+		{value: "", want: nil},
+		{value: " , ", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseTags(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTags(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRunProjectListFilters(t *testing.T) {
+	setupTestHome(t)
+	projects := map[string]storage.Project{
+		"shop":    {Path: "/srv/shop", Client: "Acme", Tags: []string{"symfony", "Docker"}},
+		"blog":    {Path: "/srv/blog", Tags: []string{"hugo"}, Status: storage.ProjectPaused},
+		"intra":   {Path: "/srv/intra", Client: "acme", Tags: []string{"docker"}, Status: storage.ProjectArchived},
+		"landing": {Path: "/srv/landing", Client: "Globex", Status: storage.ProjectActive},
+	}
+	if err := storage.SaveProjects(projects); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		outputFormat = ""
+		projectListTag, projectListClient, projectListStatus, projectListAll = "", "", "", false
+	})
+	outputFormat = "json"
+
+	tests := []struct {
+		name   string
+		tag    string
+		client string
+		status string
+		all    bool
+		want   []string
+	}{
+		{name: "archived projects hidden", want: []string{"blog", "landing", "shop"}},
+		{name: "all", all: true, want: []string{"blog", "intra", "landing", "shop"}},
+		{name: "client ignores case", client: "ACME", want: []string{"shop"}},
+		{name: "client with archived", client: "acme", all: true, want: []string{"intra", "shop"}},
+		{name: "tag ignores case", tag: "docker", all: true, want: []string{"intra", "shop"}},
+		{name: "status archived", status: storage.ProjectArchived, want: []string{"intra"}},
+		{name: "status active", status: storage.ProjectActive, want: []string{"landing", "shop"}},
+		{name: "combined filters", client: "acme", tag: "symfony", all: true, want: []string{"shop"}},
+		{name: "nothing matches", tag: "rails", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectListTag, projectListClient, projectListStatus, projectListAll = tt.tag, tt.client, tt.status, tt.all
+			out := captureStdout(t, func() {
+				if err := runProjectList(projectListCmd, nil); err != nil {
+					t.Fatal(err)
+				}
+			})
+			var listed []projectOutput
+			if err := json.Unmarshal([]byte(out), &listed); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			names := []string{}
+			for _, p := range listed {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	projectListTag, projectListClient, projectListAll = "", "", false
+	projectListStatus = "done"
+	if err := runProjectList(projectListCmd, nil); err == nil || !strings.Contains(err.Error(), "unknown status 'done'") {
+		t.Errorf("unknown status: %v", err)
+	}
+}
@@ -7,10 +7,30 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "al",
 	Short: "Al - CLI for managing client projects",
-	Long:  `Al is a CLI tool to help manage client projects with notes, links, and shortcuts.`,
+	Long: `Al is a CLI tool to help manage client projects with notes, links, and shortcuts.
+Run without arguments inside a project, it shows the project dashboard (al status).`,
+	RunE: runRoot,
+	// Replaced by completionCmd which also registers the alias binaries
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
 	},
+	// Errors are reported by PrintError
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := cmd.Annotations[shellOutputAnnotation]; ok {
+			outputFormat = ""
+			cmd.SilenceUsage = true
+			return nil
+		}
+		applyDefaultOutput(cmd)
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if isStructuredOutput() {
+			cmd.SilenceUsage = true
+		}
+		return nil
+	},
 }
 
 func Execute() error {
@@ -18,15 +38,25 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format (json, yaml, tsv)")
+
 	// Project commands
 	initCmd.GroupID = "project"
 	goCmd.GroupID = "project"
+	statusCmd.GroupID = "project"
+	projectCmd.GroupID = "project"
+	clientCmd.GroupID = "project"
 	noteCmd.GroupID = "project"
 	linkCmd.GroupID = "project"
+	templateCmd.GroupID = "project"
+	envCmd.GroupID = "project"
+	configCmd.GroupID = "setup"
+	migrateCmd.GroupID = "setup"
 	
 	// Setup commands
 	installCmd.GroupID = "setup"
 	updateCmd.GroupID = "setup"
+	completionCmd.GroupID = "setup"
 	
 	// Add command groups
 	rootCmd.AddGroup(&cobra.Group{
@@ -41,8 +71,16 @@ func init() {
 	// Add commands (order matters within groups)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(goCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(projectCmd)
+	rootCmd.AddCommand(clientCmd)
 	rootCmd.AddCommand(noteCmd)
 	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(completionCmd)
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+// globalScopeName is shown instead of a project name for global notes and links
+const globalScopeName = "global"
+
+// clientScopePrefix marks a client in --target values, as in client:acme
+const clientScopePrefix = "client:"
+
+// resolveTarget returns the scope path of a --target value: a project
+// shortcut, or client:<name> for the notes and links of a client
+func resolveTarget(target string) (string, error) {
+	if name, ok := strings.CutPrefix(target, clientScopePrefix); ok {
+		clientName, _, err := storage.FindClient(name)
+		if err != nil {
+			return "", fmt.Errorf("client '%s' not found", name)
+		}
+		return storage.GetClientDir(clientName), nil
+	}
+
+	_, project, err := storage.FindProjectByShortcut(target)
+	if err != nil {
+		return "", fmt.Errorf("project '%s' not found", target)
+	}
+	return project.Path, nil
+}
+
+// clientNameOfDir returns the registered name of the client stored in dir
+func clientNameOfDir(dir string) string {
+	clients, err := storage.LoadClients()
+	if err == nil {
+		for name := range clients {
+			if storage.GetClientDir(name) == filepath.Clean(dir) {
+				return name
+			}
+		}
+	}
+	return filepath.Base(dir)
+}
+
+// resolveScopePath returns the project whose notes and links are used: the
+// targeted project, the project containing the current directory, or an
+// empty path for the global scope stored in ~/.al_global
+func resolveScopePath(target string, global bool) (string, error) {
+	if global && target != "" {
+		return "", fmt.Errorf("--global and --target cannot be used together")
+	}
+	if global {
+		return "", nil
+	}
+
+	if target != "" {
+		return resolveTarget(target)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	_, project, err := storage.FindProjectByPath(cwd)
+	if err != nil {
+		return "", fmt.Errorf("current directory is not an al project (use -t <project>, or -g for global notes and links)")
+	}
+	return project.Path, nil
+}
+
+// resolveProjectPath is resolveScopePath for the commands that only work on
+// registered projects, such as al status and al project set
+func resolveProjectPath(target string) (string, error) {
+	if name, ok := strings.CutPrefix(target, clientScopePrefix); ok {
+		return "", fmt.Errorf("%s is a client, not a project (see al client show %s)", target, name)
+	}
+	return resolveScopePath(target, false)
+}
+
+// ensureDataDir creates a notes or links directory, which only exists
+// beforehand for projects created by al init
+func ensureDataDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return nil
+}
+
+// markAllProjectsExclusive rejects --all-projects combined with the -t and
+// -g flags of its parent command. It must be called once cmd is added.
+func markAllProjectsExclusive(cmd *cobra.Command) {
+	cmd.MarkFlagsMutuallyExclusive("all-projects", "target")
+	cmd.MarkFlagsMutuallyExclusive("all-projects", "global")
+}
+
+// namedProject is a registered project whose data is listed, checked or exported
+type namedProject struct {
+	name string
+	path string
+}
+
+// getNamedProjects returns the current project, or every registered project
+// and client sorted by name when allProjects is set. Projects whose directory
+// is missing are skipped with a warning.
+func getNamedProjects(allProjects bool, projectPath func() (string, error)) ([]namedProject, error) {
+	if !allProjects {
+		path, err := projectPath()
+		if err != nil {
+			return nil, err
+		}
+		name, _, err := storage.FindProjectByPath(path)
+		if path == "" {
+			name = globalScopeName
+		} else if storage.IsClientDir(path) {
+			name = clientScopePrefix + clientNameOfDir(path)
+		} else if err != nil {
+			name = filepath.Base(path)
+		}
+		return []namedProject{{name: name, path: path}}, nil
+	}
+
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var named []namedProject
+	for name, project := range projects {
+		if _, err := os.Stat(project.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping project '%s', %s is missing\n", name, project.Path)
+			continue
+		}
+		named = append(named, namedProject{name: name, path: project.Path})
+	}
+	clients, err := storage.LoadClients()
+	if err != nil {
+		return nil, err
+	}
+	for name := range clients {
+		// A client has no directory until a note or link is added to it
+		dir := storage.GetClientDir(name)
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		named = append(named, namedProject{name: clientScopePrefix + name, path: dir})
+	}
+
+	sort.Slice(named, func(i, j int) bool {
+		return named[i].name < named[j].name
+	})
+
+	return named, nil
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+// statusRecentNotes is the number of recently updated notes on the dashboard
+const statusRecentNotes = 5
+
+// pinnedCommandOutput is a code block pinned with al note pin --block
+type pinnedCommandOutput struct {
+	Note  string `json:"note"`
+	Block int    `json:"block"`
+	Lang  string `json:"lang"`
+	Code  string `json:"code"`
+}
+
+type statusOutput struct {
+	Project        string                `json:"project"`
+	Path           string                `json:"path"`
+	Shortcuts      []string              `json:"shortcuts"`
+	Client         string                `json:"client"`
+	Description    string                `json:"description"`
+	Tags           []string              `json:"tags"`
+	Status         string                `json:"status"`
+	CreatedAt      *time.Time            `json:"created_at"`
+	PinnedNotes    []noteOutput          `json:"pinned_notes"`
+	PinnedLinks    []linkOutput          `json:"pinned_links"`
+	PinnedCommands []pinnedCommandOutput `json:"pinned_commands"`
+	RecentNotes    []noteOutput          `json:"recent_notes"`
+	Notes          int                   `json:"notes"`
+	Links          int                   `json:"links"`
+	BrokenLinks    int                   `json:"broken_links"`
+}
+
+var statusTarget string
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the project dashboard",
+	Long: `Show a summary of the project: its shortcuts, pinned notes, links and
+commands, recently updated notes, and the number of links and of broken links
+found by the last al link check. Running al without arguments inside a project shows
+the same dashboard.
+
+Example: al status
+         al status -t client2`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              runStatus,
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&statusTarget, "target", "t", "", "Target project")
+	statusCmd.RegisterFlagCompletionFunc("target", completeProjects)
+}
+
+// runRoot shows the dashboard when al is run without arguments inside a
+// project, and the help anywhere else
+func runRoot(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cmd.Help()
+	}
+	if _, _, err := storage.FindProjectByPath(cwd); err != nil {
+		return cmd.Help()
+	}
+	return runStatus(cmd, args)
+}
+
+// buildStatus gathers the dashboard of a project
+func buildStatus(projectPath string) (*statusOutput, error) {
+	name, project, err := storage.FindProjectByPath(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := listNotes(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	links, err := listLinks(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := newProjectOutput(name, project)
+	status := &statusOutput{
+		Project:        info.Name,
+		Path:           info.Path,
+		Shortcuts:      info.Shortcuts,
+		Client:         info.Client,
+		Description:    info.Description,
+		Tags:           info.Tags,
+		Status:         info.Status,
+		CreatedAt:      info.CreatedAt,
+		PinnedNotes:    []noteOutput{},
+		PinnedLinks:    []linkOutput{},
+		PinnedCommands: []pinnedCommandOutput{},
+		RecentNotes:    []noteOutput{},
+		Notes:          len(notes),
+		Links:          len(links),
+	}
+
+	for _, note := range notes {
+		if note.Pinned {
+			status.PinnedNotes = append(status.PinnedNotes, newNoteOutput(note))
+		}
+		// Blocks changed outside of al no longer match their pin and are left out
+		numbers := pinnedBlockNumbers(note)
+		if len(numbers) == 0 {
+			continue
+		}
+		blocks := codeBlocks(note.Content)
+		for _, number := range numbers {
+			block := blocks[number-1]
+			status.PinnedCommands = append(status.PinnedCommands, pinnedCommandOutput{Note: note.Name, Block: number, Lang: block.Lang, Code: block.Code})
+		}
+	}
+	for _, link := range links {
+		if link.Pinned {
+			status.PinnedLinks = append(status.PinnedLinks, newLinkOutput(link))
+		}
+		if link.Check != nil && link.Check.Broken() {
+			status.BrokenLinks++
+		}
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].UpdatedAt.After(notes[j].UpdatedAt)
+	})
+	for _, note := range notes[:min(len(notes), statusRecentNotes)] {
+		status.RecentNotes = append(status.RecentNotes, newNoteOutput(note))
+	}
+
+	return status, nil
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	projectPath, err := resolveProjectPath(statusTarget)
+	if err != nil {
+		return err
+	}
+
+	status, err := buildStatus(projectPath)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		return printOutput(status)
+	}
+
+	config := effectiveConfig(projectPath)
+	renderer := markdownRenderer{color: useColor()}
+	heading := func(title string) {
+		fmt.Println()
+		fmt.Println(renderer.style(title, ansiBold))
+	}
+
+	fmt.Printf("%s  %s\n", renderer.style(status.Project, ansiBold, ansiMagenta), renderer.style(status.Path, ansiDim))
+	if status.Description != "" {
+		fmt.Println(status.Description)
+	}
+	fmt.Printf("Shortcuts: %s\n", strings.Join(status.Shortcuts, ", "))
+	if status.Client != "" {
+		fmt.Printf("Client: %s\n", status.Client)
+	}
+	if status.Status != storage.ProjectActive {
+		fmt.Printf("Status: %s\n", status.Status)
+	}
+	if len(status.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(status.Tags, ", "))
+	}
+
+	if len(status.PinnedNotes)+len(status.PinnedLinks)+len(status.PinnedCommands) > 0 {
+		heading("Pinned")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, note := range status.PinnedNotes {
+			fmt.Fprintf(w, "  note\t%s\t%s\n", note.Name, note.UpdatedAt.Format(config.DateFormat))
+		}
+		for _, link := range status.PinnedLinks {
+			fmt.Fprintf(w, "  link\t%s\t%s\n", link.Name, link.URL)
+		}
+		for _, c := range status.PinnedCommands {
+			code := utils.TruncateString(strings.ReplaceAll(c.Code, "\n", " ; "), config.PreviewLength)
+			fmt.Fprintf(w, "  command\t%s %d\t%s\n", c.Note, c.Block, code)
+		}
+		w.Flush()
+		if len(status.PinnedCommands) > 0 {
+			fmt.Println(renderer.style("  Run a command with al note run #<note> <block>", ansiDim))
+		}
+	}
+
+	if len(status.RecentNotes) > 0 {
+		heading("Recent notes")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, note := range status.RecentNotes {
+			fmt.Fprintf(w, "  %s\t%s\n", note.Name, note.UpdatedAt.Format(config.DateFormat))
+		}
+		w.Flush()
+	}
+
+	fmt.Println()
+	fmt.Printf("Notes: %d   Links: %d", status.Notes, status.Links)
+	if status.BrokenLinks > 0 {
+		fmt.Printf(" (%s)", renderer.style(fmt.Sprintf("%d broken", status.BrokenLinks), ansiRed))
+	}
+	fmt.Println()
+	return nil
+}
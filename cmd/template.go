@@ -0,0 +1,526 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex/al/storage"
+	"github.com/alex/al/utils"
+	"github.com/spf13/cobra"
+)
+
+// Template kinds: a note template is the Markdown text of a note, a bundle
+// lists the notes and links scaffolded by al init --with-template
+const (
+	templateKindNote   = "note"
+	templateKindBundle = "bundle"
+)
+
+// templateBundle is the JSON content of a bundle template
+type templateBundle struct {
+	Notes []bundleNote `json:"notes"`
+	Links []bundleLink `json:"links"`
+}
+
+// bundleNote is a note of a bundle, with its own content or the name of a
+// note template
+type bundleNote struct {
+	Name     string `json:"name"`
+	Template string `json:"template,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Format   string `json:"format,omitempty"`
+}
+
+// bundleLink is a link of a bundle
+type bundleLink struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// templateVars are the values of the placeholders of a template
+type templateVars struct {
+	project string
+	path    string
+	name    string
+	date    time.Time
+}
+
+type templateOutput struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
+
+// bundleSkeleton is the content of a new bundle in the editor
+const bundleSkeleton = `{
+  "notes": [
+    {"name": "access", "template": "access"},
+    {"name": "contacts", "content": "# Contacts {{project}}\n", "format": "markdown"}
+  ],
+  "links": [
+    {"name": "repo", "url": "https://git.example.com/{{project}}", "keywords": ["git"]}
+  ]
+}
+`
+
+var templateBundleFlag bool
+
+var templateCmd = &cobra.Command{
+	Use:   "template [action]",
+	Short: "Manage note templates",
+	Long: `Manage the note templates shared by all projects. Actions: list, add, edit, remove
+
+A note template is Markdown text used by al note add --template. A bundle
+(al template add --bundle) lists several notes and links created at once by
+al init --with-template.
+
+Placeholders: {{project}} (project name), {{path}} (project directory),
+{{name}} (note name) and {{date}} (today, 2006-01-02).
+
+Example: al template add access
+         al note add #access --template access
+         al template add onboarding --bundle
+         al init acme --with-template onboarding`,
+	Args: cobra.NoArgs,
+	RunE: runTemplateList,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplateList,
+}
+
+var templateAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Create a template in the editor",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplateAdd,
+}
+
+var templateEditCmd = &cobra.Command{
+	Use:               "edit [name]",
+	Short:             "Edit a template",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTemplateNames,
+	RunE:              runTemplateEdit,
+}
+
+var templateRemoveCmd = &cobra.Command{
+	Use:               "remove [name]",
+	Short:             "Remove a template",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTemplateNames,
+	RunE:              runTemplateRemove,
+}
+
+func init() {
+	templateAddCmd.Flags().BoolVar(&templateBundleFlag, "bundle", false, "Create a bundle of notes and links for al init --with-template")
+
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateAddCmd)
+	templateCmd.AddCommand(templateEditCmd)
+	templateCmd.AddCommand(templateRemoveCmd)
+}
+
+func getTemplatesDir() (string, error) {
+	globalDir, err := storage.GetGlobalDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(globalDir, "templates"), nil
+}
+
+// templateExtension is the file extension of a kind of template
+func templateExtension(kind string) string {
+	if kind == templateKindBundle {
+		return ".json"
+	}
+	return ".md"
+}
+
+// templateFilePath returns the file of a template, named after its
+// slugified name
+func templateFilePath(name, kind string) (string, error) {
+	dir, err := getTemplatesDir()
+	if err != nil {
+		return "", err
+	}
+	stem := strings.TrimSuffix(itemFileName(name), ".json")
+	return filepath.Join(dir, stem+templateExtension(kind)), nil
+}
+
+// findTemplate returns the file and the kind of an existing template
+func findTemplate(name string) (string, string, error) {
+	for _, kind := range []string{templateKindNote, templateKindBundle} {
+		path, err := templateFilePath(name, kind)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, kind, nil
+		}
+	}
+	return "", "", os.ErrNotExist
+}
+
+// listTemplates returns the templates sorted by name
+func listTemplates() ([]templateOutput, error) {
+	dir, err := getTemplatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var templates []templateOutput
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		kind := templateKindNote
+		switch ext {
+		case ".md":
+		case ".json":
+			kind = templateKindBundle
+		default:
+			continue
+		}
+		templates = append(templates, templateOutput{
+			Name: strings.TrimSuffix(entry.Name(), ext),
+			Kind: kind,
+			Path: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// templateNames returns the names of the templates of a kind, or of any kind
+// when kind is empty
+func templateNames(kind string) []string {
+	templates, _ := listTemplates()
+	var names []string
+	for _, t := range templates {
+		if kind == "" || t.Kind == kind {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+func reportTemplateNotFound(name, kind string) error {
+	return reportNotFound("template", name, utils.FindSimilarStrings(name, templateNames(kind), 3))
+}
+
+// loadNoteTemplate returns the text of a note template
+func loadNoteTemplate(name string) (string, error) {
+	path, err := templateFilePath(name, templateKindNote)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseTemplateBundle reads a bundle and checks its notes and links
+func parseTemplateBundle(data []byte) (*templateBundle, error) {
+	var bundle templateBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, err
+	}
+	for _, note := range bundle.Notes {
+		if err := validateName("note", note.Name); err != nil {
+			return nil, err
+		}
+		if note.Format != "" && !contains(noteFormats, note.Format) {
+			return nil, fmt.Errorf("note '%s': unknown format '%s' (expected plain or markdown)", note.Name, note.Format)
+		}
+	}
+	for _, link := range bundle.Links {
+		if err := validateName("link", link.Name); err != nil {
+			return nil, err
+		}
+		if link.URL == "" {
+			return nil, fmt.Errorf("link '%s' has no URL", link.Name)
+		}
+	}
+	return &bundle, nil
+}
+
+// loadTemplateBundle reads a bundle template, with the content of the note
+// templates it uses so that nothing is missing once notes are created
+func loadTemplateBundle(name string) (*templateBundle, error) {
+	path, err := templateFilePath(name, templateKindBundle)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := parseTemplateBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template bundle '%s': %w", name, err)
+	}
+
+	for i, item := range bundle.Notes {
+		if item.Template == "" {
+			continue
+		}
+		text, err := loadNoteTemplate(item.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template bundle '%s': note '%s': template '%s' not found", name, item.Name, item.Template)
+		}
+		bundle.Notes[i].Content = text
+		if item.Format == "" {
+			bundle.Notes[i].Format = noteFormatMarkdown
+		}
+	}
+	return bundle, nil
+}
+
+// newTemplateVars returns the placeholder values for a project path, the
+// global scope being named 'global'
+func newTemplateVars(projectPath, noteName string) templateVars {
+	vars := templateVars{project: globalScopeName, path: projectPath, name: noteName, date: time.Now()}
+	if projectPath != "" {
+		vars.project = scopeLabel(projectPath)
+	}
+	return vars
+}
+
+// expandTemplate replaces the known placeholders of a template. Other
+// {{variables}} are kept, such as the variables of link URLs.
+func expandTemplate(text string, vars templateVars) string {
+	return linkPlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+		switch linkPlaceholder.FindStringSubmatch(m)[1] {
+		case "project":
+			return vars.project
+		case "path":
+			return vars.path
+		case "name":
+			return vars.name
+		case "date":
+			return vars.date.Format("2006-01-02")
+		}
+		return m
+	})
+}
+
+// applyTemplateBundle creates the notes and links of a bundle in a project.
+// Existing notes and links are left untouched.
+func applyTemplateBundle(projectPath string, bundle *templateBundle) error {
+	now := time.Now()
+
+	for _, item := range bundle.Notes {
+		if existing, err := loadNoteFile(projectPath, item.Name); err == nil {
+			fmt.Printf("  Note '%s' already exists, skipped\n", existing.Name)
+			continue
+		}
+
+		content := item.Content
+		format := item.Format
+		if format == "" {
+			format = effectiveConfig(projectPath).NoteFormat
+		}
+
+		note := &Note{
+			Name:      item.Name,
+			Content:   expandTemplate(content, newTemplateVars(projectPath, item.Name)),
+			Format:    format,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := saveNote(projectPath, note); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Note '%s' created\n", note.Name)
+	}
+
+	for _, item := range bundle.Links {
+		if existing, err := loadLinkFile(projectPath, item.Name); err == nil {
+			fmt.Printf("  Link '%s' already exists, skipped\n", existing.Name)
+			continue
+		}
+
+		link := &Link{
+			Name:      item.Name,
+			URL:       expandTemplate(item.URL, newTemplateVars(projectPath, item.Name)),
+			Keywords:  item.Keywords,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := saveLink(projectPath, link); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Link '%s' created\n", link.Name)
+	}
+
+	return nil
+}
+
+// editTemplateFile opens a template in the editor through a temporary copy.
+// Bundles are only saved when they are valid; otherwise the edited text is
+// left in the temporary file.
+func editTemplateFile(path, kind, initial string) error {
+	tmpFile := filepath.Join(os.TempDir(), "al_template_"+filepath.Base(path))
+	if err := os.WriteFile(tmpFile, []byte(initial), 0600); err != nil {
+		return err
+	}
+
+	if err := openEditor("", tmpFile); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		return err
+	}
+	if kind == templateKindBundle {
+		if _, err := parseTemplateBundle(data); err != nil {
+			return fmt.Errorf("invalid template bundle: %v (your changes are kept in %s)", err, tmpFile)
+		}
+	}
+	os.Remove(tmpFile)
+
+	if err := ensureDataDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// completeTemplateNames completes the names of all templates
+func completeTemplateNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completionCandidates(templateNames(""), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNoteTemplates completes the names of the note templates
+func completeNoteTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completionCandidates(templateNames(templateKindNote), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBundleTemplates completes the names of the bundles
+func completeBundleTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completionCandidates(templateNames(templateKindBundle), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	templates, err := listTemplates()
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		if templates == nil {
+			templates = []templateOutput{}
+		}
+		return printOutput(templates)
+	}
+
+	if len(templates) == 0 {
+		fmt.Println("No templates found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tKind\tPath")
+	fmt.Fprintln(w, "----\t----\t----")
+	for _, t := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, t.Kind, t.Path)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runTemplateAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := validateName("template", name); err != nil {
+		return err
+	}
+
+	if path, _, err := findTemplate(name); err == nil {
+		return fmt.Errorf("template '%s' already exists (%s)", name, path)
+	}
+
+	kind, initial := templateKindNote, ""
+	if templateBundleFlag {
+		kind, initial = templateKindBundle, bundleSkeleton
+	}
+
+	path, err := templateFilePath(name, kind)
+	if err != nil {
+		return err
+	}
+	if err := editTemplateFile(path, kind, initial); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Template '%s' created\n", name)
+	return nil
+}
+
+func runTemplateEdit(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	path, kind, err := findTemplate(name)
+	if err != nil {
+		return reportTemplateNotFound(name, "")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := editTemplateFile(path, kind, string(data)); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Template '%s' updated\n", name)
+	return nil
+}
+
+func runTemplateRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	path, _, err := findTemplate(name)
+	if err != nil {
+		return reportTemplateNotFound(name, "")
+	}
+
+	if !utils.AskConfirmation(fmt.Sprintf("Are you sure you want to delete template '%s'?", name)) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Template '%s' deleted\n", name)
+	return nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex/al/storage"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := templateVars{project: "web", path: "/srv/web", name: "runbook", date: time.Date(2026, 3, 15, 23, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "# {{name}} — {{project}}", want: "# runbook — web"},
+		{in: "cd {{ path }} # {{date}}", want: "cd /srv/web # 2026-03-15"},
+		{in: "{{project}}{{project}}", want: "webweb"},
+		// Other variables are left for link URLs and al link open
+		{in: "https://jira.example.com/{{issue}}", want: "https://jira.example.com/{{issue}}"},
+		{in: "{{Name}} {name} {{ }}", want: "{{Name}} {name} {{ }}"},
+	}
+
+	for _, tt := range tests {
+		if got := expandTemplate(tt.in, vars); got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewTemplateVars(t *testing.T) {
+	setupTestHome(t)
+	project := t.TempDir()
+	if err := storage.SaveProjects(map[string]storage.Project{"web": {Path: project}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveClients(map[string]storage.Client{"Acme": {}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":                           globalScopeName,
+		project:                      "web",
+		storage.GetClientDir("Acme"): "client:Acme",
+	}
+	for path, want := range tests {
+		vars := newTemplateVars(path, "n")
+		if vars.project != want || vars.path != path || vars.name != "n" {
+			t.Errorf("newTemplateVars(%q) = %+v, want project %q", path, vars, want)
+		}
+	}
+}
+
+func TestParseTemplateBundle(t *testing.T) {
+	bundle, err := parseTemplateBundle([]byte(`{
+		"notes": [{"name": "runbook", "template": "runbook"}, {"name": "todo", "content": "- [ ] x", "format": "plain"}],
+		"links": [{"name": "repo", "url": "https://git.example.com/{{project}}", "keywords": ["git"]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Notes) != 2 || bundle.Notes[0].Template != "runbook" || bundle.Notes[1].Format != "plain" || len(bundle.Links) != 1 || bundle.Links[0].Keywords[0] != "git" {
+		t.Errorf("got %+v", bundle)
+	}
+
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{data: `{"notes": [`, wantErr: "unexpected end of JSON input"},
+		{data: `{"notes": [{"name": "../x"}]}`, wantErr: "'.' and '..' are not allowed"},
+		{data: `{"notes": [{"name": ""}]}`, wantErr: "cannot be empty"},
+		{data: `{"notes": [{"name": "x", "format": "html"}]}`, wantErr: "note 'x': unknown format 'html'"},
+		{data: `{"links": [{"name": "repo"}]}`, wantErr: "link 'repo' has no URL"},
+		{data: `{"links": [{"name": "--", "url": "u"}]}`, wantErr: "must contain a letter or a digit"},
+	}
+	for _, tt := range tests {
+		if _, err := parseTemplateBundle([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseTemplateBundle(%s) = %v, want %q", tt.data, err, tt.wantErr)
+		}
+	}
+}
+
+// writeTemplate writes a template of the given kind
+func writeTemplate(t *testing.T, name, kind, text string) {
+	t.Helper()
+	path, err := templateFilePath(name, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAndApplyTemplateBundle(t *testing.T) {
+	setupTestHome(t)
+	writeTemplate(t, "Runbook", templateKindNote, "# {{name}} of {{project}}\n")
+	writeTemplate(t, "web app", templateKindBundle, `{
+		"notes": [{"name": "runbook", "template": "runbook"}, {"name": "todo", "content": "{{date}}", "format": "plain"}],
+		"links": [{"name": "repo", "url": "https://git.example.com/{{project}}/{{branch}}"}]
+	}`)
+	writeTemplate(t, "broken", templateKindBundle, `{"notes": [{"name": "x", "template": "missing"}]}`)
+
+	if _, err := loadTemplateBundle("broken"); err == nil || !strings.Contains(err.Error(), "template 'missing' not found") {
+		t.Errorf("loadTemplateBundle(broken) = %v", err)
+	}
+
+	bundle, err := loadTemplateBundle("Web App")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Notes[0].Content != "# {{name}} of {{project}}\n" || bundle.Notes[0].Format != noteFormatMarkdown {
+		t.Errorf("note template not loaded: %+v", bundle.Notes[0])
+	}
+
+	if err := saveNote("", &Note{Name: "todo", Content: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		if err := applyTemplateBundle("", bundle); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "Note 'todo' already exists, skipped") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if note, err := loadNote("", "runbook"); err != nil || note.Content != "# runbook of global\n" {
+		t.Errorf("runbook = %+v, %v", note, err)
+	}
+	if note, err := loadNote("", "todo"); err != nil || note.Content != "kept" {
+		t.Errorf("existing note overwritten: %+v, %v", note, err)
+	}
+	if link, err := loadLink("", "repo"); err != nil || link.URL != "https://git.example.com/global/{{branch}}" {
+		t.Errorf("repo = %+v, %v", link, err)
+	}
+}
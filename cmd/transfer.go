@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	noteTransferTo    string
+	noteTransferForce bool
+	linkTransferTo    string
+	linkTransferForce bool
+)
+
+var noteMvCmd = &cobra.Command{
+	Use:   "mv [#name] [#new-name]",
+	Short: "Rename a note or move it to another project",
+	Long: `Rename a note, or move it to another project with --to.
+Dates and encryption are kept.
+
+Example: al note mv #todo #backlog
+         al note mv #runbook --to client2`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNoteTransfer(args, true)
+	},
+}
+
+var noteCpCmd = &cobra.Command{
+	Use:   "cp [#name] [#new-name]",
+	Short: "Copy a note, possibly to another project",
+	Long: `Copy a note under a new name, or to another project with --to.
+Dates and encryption are kept.
+
+Example: al note cp #runbook #runbook-v2
+         al note cp #runbook --to client2`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNoteTransfer(args, false)
+	},
+}
+
+var linkMvCmd = &cobra.Command{
+	Use:   "mv [#name] [#new-name]",
+	Short: "Rename a link or move it to another project",
+	Long: `Rename a link, or move it to another project with --to.
+
+Example: al link mv #grafana #grafana-prod
+         al link mv #grafana --to client2`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeLinkNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLinkTransfer(args, true)
+	},
+}
+
+var linkCpCmd = &cobra.Command{
+	Use:   "cp [#name] [#new-name]",
+	Short: "Copy a link, possibly to another project",
+	Long: `Copy a link under a new name, or to another project with --to.
+
+Example: al link cp #grafana #grafana-staging
+         al link cp #grafana --to client2`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeLinkNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLinkTransfer(args, false)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{noteMvCmd, noteCpCmd} {
+		c.Flags().StringVar(&noteTransferTo, "to", "", "Destination project (or 'global')")
+		c.RegisterFlagCompletionFunc("to", completeTransferDestinations)
+		c.Flags().BoolVarP(&noteTransferForce, "force", "f", false, "Overwrite an existing note")
+		noteCmd.AddCommand(c)
+	}
+
+	for _, c := range []*cobra.Command{linkMvCmd, linkCpCmd} {
+		c.Flags().StringVar(&linkTransferTo, "to", "", "Destination project (or 'global')")
+		c.RegisterFlagCompletionFunc("to", completeTransferDestinations)
+		c.Flags().BoolVarP(&linkTransferForce, "force", "f", false, "Overwrite an existing link")
+		linkCmd.AddCommand(c)
+	}
+}
+
+// completeTransferDestinations completes the project shortcuts, the clients
+// and 'global'
+func completeTransferDestinations(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates, directive := completeTargets(cmd, args, toComplete)
+	if strings.HasPrefix(globalScopeName, toComplete) {
+		candidates = append(candidates, globalScopeName+"\tGlobal notes and links")
+	}
+	return candidates, directive
+}
+
+// transferDestination resolves the project and the name a note or link is
+// moved or copied to. --to global targets the global scope, unless a
+// project uses that shortcut.
+func transferDestination(kind, sourcePath, name string, args []string, to string) (string, string, error) {
+	destPath := sourcePath
+	if to != "" {
+		path, err := resolveTarget(to)
+		switch {
+		case err == nil:
+			destPath = path
+		case to == globalScopeName:
+			destPath = ""
+		default:
+			return "", "", err
+		}
+	}
+
+	newName := name
+	if len(args) == 2 {
+		newName = strings.TrimPrefix(args[1], "#")
+	}
+	if err := validateName(kind, newName); err != nil {
+		return "", "", err
+	}
+
+	if destPath == sourcePath && newName == name {
+		return "", "", fmt.Errorf("%s '%s' is already in %s: give a new name or another project with --to", kind, name, scopeLabel(sourcePath))
+	}
+	return destPath, newName, nil
+}
+
+// scopeLabel names a project path in messages
+func scopeLabel(projectPath string) string {
+	if projectPath == "" {
+		return globalScopeName
+	}
+	if storage.IsClientDir(projectPath) {
+		return clientScopePrefix + clientNameOfDir(projectPath)
+	}
+	if name, _, err := storage.FindProjectByPath(projectPath); err == nil {
+		return name
+	}
+	return projectPath
+}
+
+func runNoteTransfer(args []string, move bool) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	noteName := strings.TrimPrefix(args[0], "#")
+
+	note, err := loadNote(projectPath, noteName)
+	if err != nil {
+		return reportNoteNotFound(projectPath, noteName, err)
+	}
+
+	destPath, newName, err := transferDestination("note", projectPath, noteName, args, noteTransferTo)
+	if err != nil {
+		return err
+	}
+
+	// Names differing only by case or punctuation share the same file
+	sourceFile := getNoteFilePath(projectPath, noteName)
+	sameFile := sameItemFile(sourceFile, getNoteFilePath(destPath, newName))
+
+	if existing, err := loadNoteFile(destPath, newName); err == nil && !sameFile && !noteTransferForce {
+		return fmt.Errorf("note '%s' already exists in %s (use --force to overwrite)", existing.Name, scopeLabel(destPath))
+	}
+	if sameFile && !move {
+		return fmt.Errorf("'%s' and '%s' are the same note", noteName, newName)
+	}
+
+	if err := copyAttachments(projectPath, destPath, note.Attachments); err != nil {
+		return err
+	}
+
+	// An overwritten note may leave attachments nothing refers to
+	var replaced []Attachment
+	if existing, err := loadNoteFile(destPath, newName); err == nil && !sameFile {
+		replaced = existing.Attachments
+	}
+
+	note.Name = newName
+	if err := saveNote(destPath, note); err != nil {
+		return err
+	}
+	if err := removeUnusedAttachments(destPath, replaced); err != nil {
+		return err
+	}
+
+	if move {
+		if !sameFile {
+			if err := os.Remove(sourceFile); err != nil {
+				return err
+			}
+			if err := removeUnusedAttachments(projectPath, note.Attachments); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("✓ Note '%s' moved to '%s' (%s)\n", noteName, newName, scopeLabel(destPath))
+		return nil
+	}
+
+	fmt.Printf("✓ Note '%s' copied to '%s' (%s)\n", noteName, newName, scopeLabel(destPath))
+	return nil
+}
+
+func runLinkTransfer(args []string, move bool) error {
+	projectPath, err := getLinkProjectPath()
+	if err != nil {
+		return err
+	}
+
+	linkName := strings.TrimPrefix(args[0], "#")
+
+	link, err := loadLink(projectPath, linkName)
+	if err != nil {
+		return reportLinkNotFound(projectPath, linkName, err)
+	}
+
+	destPath, newName, err := transferDestination("link", projectPath, linkName, args, linkTransferTo)
+	if err != nil {
+		return err
+	}
+
+	// Names differing only by case or punctuation share the same file
+	sourceFile := getLinkFilePath(projectPath, linkName)
+	sameFile := sameItemFile(sourceFile, getLinkFilePath(destPath, newName))
+
+	if existing, err := loadLinkFile(destPath, newName); err == nil && !sameFile && !linkTransferForce {
+		return fmt.Errorf("link '%s' already exists in %s (use --force to overwrite)", existing.Name, scopeLabel(destPath))
+	}
+	if sameFile && !move {
+		return fmt.Errorf("'%s' and '%s' are the same link", linkName, newName)
+	}
+
+	if destPath != projectPath {
+		if err := copyLinkVars(projectPath, destPath, link.URL); err != nil {
+			return err
+		}
+	}
+
+	link.Name = newName
+	if err := saveLink(destPath, link); err != nil {
+		return err
+	}
+
+	if move {
+		if !sameFile {
+			if err := os.Remove(sourceFile); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("✓ Link '%s' moved to '%s' (%s)\n", linkName, newName, scopeLabel(destPath))
+		return nil
+	}
+
+	fmt.Printf("✓ Link '%s' copied to '%s' (%s)\n", linkName, newName, scopeLabel(destPath))
+	return nil
+}
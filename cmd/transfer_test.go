@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex/al/storage"
+)
+
+// writeLegacyNote writes a note file named after the raw note name, as older
+// versions did
+func writeLegacyNote(t *testing.T, projectPath, name string) string {
+	t.Helper()
+	dir := getNotesDir(projectPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(Note{Name: name, Content: "content of " + name})
+	path := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNoteMoveLegacyCaseOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		// caseInsensitive links foo.json to Foo.json, as a case insensitive
+		// file system resolves both names to the same file
+		caseInsensitive bool
+		force           bool
+	}{
+		{name: "case sensitive"},
+		{name: "case insensitive", caseInsensitive: true},
+		{name: "case insensitive with force", caseInsensitive: true, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Cleanup(func() { noteGlobal, noteTransferForce = false, false })
+			noteGlobal, noteTransferForce = true, tt.force
+
+			legacy := writeLegacyNote(t, "", "Foo")
+			if tt.caseInsensitive {
+				if err := os.Link(legacy, filepath.Join(getNotesDir(""), "foo.json")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := runNoteTransfer([]string{"#Foo", "foo"}, true); err != nil {
+				t.Fatal(err)
+			}
+
+			note, err := loadNote("", "foo")
+			if err != nil {
+				t.Fatalf("note lost: %v", err)
+			}
+			if note.Name != "foo" || note.Content != "content of Foo" {
+				t.Errorf("got %+v", note)
+			}
+		})
+	}
+}
+
+// setupTestHome gives the test an empty home with an al global directory
+func setupTestHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	globalDir, err := storage.GetGlobalDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(globalDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinkTransferCopiesVars(t *testing.T) {
+	setupTestHome(t)
+	project := t.TempDir()
+	if err := storage.SaveProjects(map[string]storage.Project{"acme": {Path: project, Shortcuts: []string{"acme"}}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { linkGlobal, linkTransferTo = false, "" })
+	linkGlobal, linkTransferTo = true, "acme"
+
+	if err := saveLink("", &Link{Name: "grafana", URL: "https://grafana.example.com/{{env}}/{{team}}"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLinkVars("", map[string]string{"env": "prod", "team": "ops", "other": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLinkVars(project, map[string]string{"team": "web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runLinkTransfer([]string{"#grafana"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := loadLinkVars(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"env": "prod", "team": "web"}
+	if len(vars) != len(want) {
+		t.Fatalf("vars = %v, want %v", vars, want)
+	}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("vars[%s] = %q, want %q", name, vars[name], value)
+		}
+	}
+
+	// Other links of the source may still use its values
+	source, _ := loadLinkVars("")
+	if source["env"] != "prod" {
+		t.Errorf("source vars changed: %v", source)
+	}
+}
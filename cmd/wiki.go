@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alex/al/storage"
+	"github.com/spf13/cobra"
+)
+
+// wikiRef matches the [[note]], [[project:note]] and [[link:name]]
+// references of a note. Brackets with inner spaces such as the shell
+// [[ -f file ]] tests are not references.
+var wikiRef = regexp.MustCompile(`\[\[([^\[\]\s]|[^\[\]\s][^\[\]\n]*[^\[\]\s])\]\]`)
+
+// linkRefPrefix marks a reference to a link of the same scope
+const linkRefPrefix = "link:"
+
+// noteRef is a reference found in a note
+type noteRef struct {
+	Raw     string // text between the brackets
+	Project string // project shortcut or 'global', empty for the note scope
+	Link    bool
+	Name    string
+}
+
+// parseNoteRef reads the text between the brackets of a reference
+func parseNoteRef(raw string) noteRef {
+	ref := noteRef{Raw: raw, Name: strings.TrimSpace(raw)}
+	if rest, ok := strings.CutPrefix(ref.Name, linkRefPrefix); ok {
+		ref.Link, ref.Name = true, strings.TrimSpace(rest)
+	} else if project, name, ok := strings.Cut(ref.Name, ":"); ok && isScopePrefix(strings.TrimSpace(project)) {
+		ref.Project, ref.Name = strings.TrimSpace(project), strings.TrimSpace(name)
+		// [[client:acme:note]] refers to a note of a client
+		if client, name, ok := strings.Cut(ref.Name, ":"); ok && ref.Project+":" == clientScopePrefix {
+			ref.Project, ref.Name = clientScopePrefix+strings.TrimSpace(client), strings.TrimSpace(name)
+		}
+	}
+	ref.Name = strings.TrimPrefix(ref.Name, "#")
+	return ref
+}
+
+// isScopePrefix reports whether the text before ':' in a reference names a
+// scope, so that [[https://example.com]] is not read as project 'https'
+func isScopePrefix(prefix string) bool {
+	if prefix == globalScopeName || prefix+":" == clientScopePrefix {
+		return true
+	}
+	_, _, err := storage.FindProjectByShortcut(prefix)
+	return err == nil
+}
+
+// noteRefs returns the references of a note content in order, leaving out
+// code blocks and code spans
+func noteRefs(content string) []noteRef {
+	var refs []noteRef
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		if m := mdFence.FindStringSubmatch(lines[i]); m != nil {
+			// Skip the code block
+			for i++; i < len(lines) && !isFenceEnd(lines[i], m[2]); i++ {
+			}
+			continue
+		}
+		line := mdCodeSpan.ReplaceAllString(lines[i], "")
+		for _, m := range wikiRef.FindAllStringSubmatch(line, -1) {
+			refs = append(refs, parseNoteRef(m[1]))
+		}
+	}
+	return refs
+}
+
+// refResolver resolves the references of the notes of a scope
+type refResolver struct {
+	projectPath string
+}
+
+// scopePath returns the scope a reference points to
+func (r refResolver) scopePath(ref noteRef) (string, error) {
+	if ref.Project == "" {
+		return r.projectPath, nil
+	}
+	path, err := resolveTarget(ref.Project)
+	if err != nil && ref.Project == globalScopeName {
+		return "", nil
+	}
+	return path, err
+}
+
+// resolve returns the note or link a reference points to, or the reason it
+// is dangling
+func (r refResolver) resolve(ref noteRef) (*Note, *Link, error) {
+	path, err := r.scopePath(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ref.Link {
+		link, err := loadLink(path, ref.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("link '%s' not found", ref.Name)
+		}
+		return nil, link, nil
+	}
+
+	note, err := loadNote(path, ref.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("note '%s' not found in %s", ref.Name, scopeLabel(path))
+	}
+	return note, nil, nil
+}
+
+// render returns the printed form of a reference: the note name, the link
+// with its URL, or the reference marked as missing
+func (r refResolver) render(m markdownRenderer, raw string) string {
+	ref := parseNoteRef(raw)
+	note, link, err := r.resolve(ref)
+	switch {
+	case err != nil:
+		return m.style("[["+raw+"]]", ansiRed) + m.style(" (not found)", ansiDim)
+	case link != nil:
+		return link.Name + " (" + m.style(link.URL, ansiBlue, ansiUnderline) + ")"
+	case ref.Project != "":
+		return m.style(ref.Project+":"+note.Name, ansiBlue, ansiUnderline)
+	}
+	return m.style(note.Name, ansiBlue, ansiUnderline)
+}
+
+// renderRefs resolves the references of a plain text note
+func (r refResolver) renderRefs(m markdownRenderer, content string) string {
+	return wikiRef.ReplaceAllStringFunc(content, func(s string) string {
+		return r.render(m, wikiRef.FindStringSubmatch(s)[1])
+	})
+}
+
+// pointsTo reports whether a reference made from a note of the resolver
+// scope points to the note named name in targetPath
+func (r refResolver) pointsTo(ref noteRef, targetPath, name string) bool {
+	if ref.Link {
+		return false
+	}
+	path, err := r.scopePath(ref)
+	if err != nil || path != targetPath {
+		return false
+	}
+	return sameItemName(name, ref.Name)
+}
+
+type backlinkOutput struct {
+	Project   string `json:"project"`
+	Note      string `json:"note"`
+	Reference string `json:"reference"`
+}
+
+type danglingRefOutput struct {
+	Project   string `json:"project"`
+	Note      string `json:"note"`
+	Reference string `json:"reference"`
+	Problem   string `json:"problem"`
+}
+
+var noteCheckAllProjects bool
+
+var noteBacklinksCmd = &cobra.Command{
+	Use:   "backlinks [#name]",
+	Short: "List the notes referring to a note",
+	Long: `List the notes of every project and client, and the global notes, whose
+[[references]] point to a note. Encrypted notes are not searched.
+
+Example: al note backlinks #deploy`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteNames,
+	RunE:              runNoteBacklinks,
+}
+
+var noteCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report dangling references between notes",
+	Long: `Report the [[references]] of notes pointing to notes, links or projects that
+do not exist, typically after a rename or a removal. Encrypted notes are
+not checked. The exit status is 1 when dangling references are found, so
+the check can run in CI or in a git hook.
+
+Example: al note check
+         al note check --all-projects`,
+	Args: cobra.NoArgs,
+	RunE: runNoteCheck,
+}
+
+func init() {
+	noteCheckCmd.Flags().BoolVar(&noteCheckAllProjects, "all-projects", false, "Check the notes of every project and client, and the global notes")
+
+	noteCmd.AddCommand(noteBacklinksCmd)
+	noteCmd.AddCommand(noteCheckCmd)
+	markAllProjectsExclusive(noteCheckCmd)
+}
+
+// allScopes returns every project and client followed by the global scope
+func allScopes() ([]namedProject, error) {
+	projects, err := getNamedProjects(true, getProjectPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(projects, namedProject{name: globalScopeName, path: ""}), nil
+}
+
+func runNoteBacklinks(cmd *cobra.Command, args []string) error {
+	projectPath, err := getProjectPath()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNoteArg(projectPath, args[0])
+	if note == nil {
+		return err
+	}
+
+	scopes, err := allScopes()
+	if err != nil {
+		return err
+	}
+
+	backlinks := []backlinkOutput{}
+	for _, scope := range scopes {
+		notes, err := listNotes(scope.path)
+		if err != nil {
+			return err
+		}
+		resolver := refResolver{projectPath: scope.path}
+		for _, n := range notes {
+			if n.Encrypted || n.Binary {
+				continue
+			}
+			for _, ref := range noteRefs(n.Content) {
+				if resolver.pointsTo(ref, projectPath, note.Name) {
+					backlinks = append(backlinks, backlinkOutput{Project: scope.name, Note: n.Name, Reference: "[[" + ref.Raw + "]]"})
+					break
+				}
+			}
+		}
+	}
+
+	if isStructuredOutput() {
+		return printOutput(backlinks)
+	}
+
+	if len(backlinks) == 0 {
+		fmt.Printf("No notes refer to '%s'.\n", note.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Project\tNote\tReference")
+	fmt.Fprintln(w, "-------\t----\t---------")
+	for _, b := range backlinks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", b.Project, b.Note, b.Reference)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runNoteCheck(cmd *cobra.Command, args []string) error {
+	var scopes []namedProject
+	var err error
+	if noteCheckAllProjects {
+		scopes, err = allScopes()
+	} else {
+		scopes, err = getNamedProjects(false, getProjectPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	dangling := []danglingRefOutput{}
+	checked, skipped := 0, 0
+	for _, scope := range scopes {
+		notes, err := listNotes(scope.path)
+		if err != nil {
+			return err
+		}
+		resolver := refResolver{projectPath: scope.path}
+		for _, n := range notes {
+			if n.Encrypted || n.Binary {
+				skipped++
+				continue
+			}
+			for _, ref := range noteRefs(n.Content) {
+				checked++
+				if _, _, err := resolver.resolve(ref); err != nil {
+					dangling = append(dangling, danglingRefOutput{
+						Project:   scope.name,
+						Note:      n.Name,
+						Reference: "[[" + ref.Raw + "]]",
+						Problem:   err.Error(),
+					})
+				}
+			}
+		}
+	}
+
+	// Dangling references make the command fail, for CI and git hooks
+	var result error
+	if len(dangling) > 0 {
+		cmd.SilenceUsage = true
+		result = &reportedError{msg: fmt.Sprintf("%d dangling references", len(dangling))}
+	}
+
+	if isStructuredOutput() {
+		if err := printOutput(dangling); err != nil {
+			return err
+		}
+		return result
+	}
+
+	if len(dangling) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		if noteCheckAllProjects {
+			fmt.Fprintln(w, "Project\tNote\tReference\tProblem")
+			fmt.Fprintln(w, "-------\t----\t---------\t-------")
+		} else {
+			fmt.Fprintln(w, "Note\tReference\tProblem")
+			fmt.Fprintln(w, "----\t---------\t-------")
+		}
+		for _, d := range dangling {
+			if noteCheckAllProjects {
+				fmt.Fprintf(w, "%s\t", d.Project)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", d.Note, d.Reference, d.Problem)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	fmt.Printf("✓ %d references checked: %d dangling", checked, len(dangling))
+	if skipped > 0 {
+		fmt.Printf(" (%d encrypted or binary notes skipped)", skipped)
+	}
+	fmt.Println()
+	return result
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alex/al/storage"
+)
+
+// setupWikiScopes registers the project web and the client acme, each with a
+// note, and returns the project path
+func setupWikiScopes(t *testing.T) string {
+	t.Helper()
+	project := setupTestClients(t)
+	for _, scope := range []string{"", project, storage.GetClientDir("Acme Corp")} {
+		if err := saveNote(scope, &Note{Name: "VPN Setup", Content: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveLink(project, &Link{Name: "grafana", URL: "https://grafana.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestParseNoteRef(t *testing.T) {
+	setupWikiScopes(t)
+
+	tests := []struct {
+		raw  string
+		want noteRef
+	}{
+		{raw: "deploy", want: noteRef{Name: "deploy"}},
+		{raw: "#deploy", want: noteRef{Name: "deploy"}},
+		{raw: "web:deploy", want: noteRef{Project: "web", Name: "deploy"}},
+		{raw: "web: #deploy ", want: noteRef{Project: "web", Name: "deploy"}},
+		{raw: "global:todo", want: noteRef{Project: "global", Name: "todo"}},
+		{raw: "link:grafana", want: noteRef{Link: true, Name: "grafana"}},
+		{raw: "client:acme:vpn", want: noteRef{Project: "client:acme", Name: "vpn"}},
+		{raw: "client: Acme Corp : VPN Setup", want: noteRef{Project: "client:Acme Corp", Name: "VPN Setup"}},
+		// Not a scope: the whole text is the name
+		{raw: "https://example.com", want: noteRef{Name: "https://example.com"}},
+		{raw: "api:deploy", want: noteRef{Name: "api:deploy"}},
+	}
+
+	for _, tt := range tests {
+		tt.want.Raw = tt.raw
+		if got := parseNoteRef(tt.raw); got != tt.want {
+			t.Errorf("parseNoteRef(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestNoteRefs(t *testing.T) {
+	setupWikiScopes(t)
+
+	content := strings.Join([]string{
+		"See [[deploy]] and [[web:vpn]], then [[link:grafana]].",
+		"Shell tests are not references: [[ -f .env ]] && [[ $x == y ]]",
+		"Nor code spans: `[[deploy]]`",
+		"```sh",
+		"[[not-a-ref]]",
+		"```",
+		"  ~~~",
+		"  [[indented-code]]",
+		"  ~~~",
+		"[[client:acme:vpn]] [[https://example.com]] [[a]] [[ ]] [[x]]y]]",
+	}, "\n")
+
+	var got []string
+	for _, ref := range noteRefs(content) {
+		got = append(got, ref.Project+"|"+ref.Name)
+	}
+	want := []string{"|deploy", "web|vpn", "|grafana", "client:acme|vpn", "|https://example.com", "|a", "|x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRefResolver(t *testing.T) {
+	project := setupWikiScopes(t)
+	r := refResolver{projectPath: project}
+
+	for _, raw := range []string{"vpn setup", "#VPN Setup", "global:VPN Setup", "client:acme corp:vpn setup", "web:VPN Setup"} {
+		if note, _, err := r.resolve(parseNoteRef(raw)); err != nil || note.Name != "VPN Setup" {
+			t.Errorf("resolve(%q) = %v, %v", raw, note, err)
+		}
+	}
+	if _, link, err := r.resolve(parseNoteRef("link:grafana")); err != nil || link.URL != "https://grafana.example.com" {
+		t.Errorf("resolve(link:grafana) = %v, %v", link, err)
+	}
+
+	tests := map[string]string{
+		"missing":            "note 'missing' not found in web",
+		"global:missing":     "note 'missing' not found in global",
+		"client:initech:vpn": "client 'initech' not found",
+		"link:missing":       "link 'missing' not found",
+		"vpn-setup":          "note 'vpn-setup' not found",
+	}
+	for raw, want := range tests {
+		if _, _, err := r.resolve(parseNoteRef(raw)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("resolve(%q) = %v, want %q", raw, err, want)
+		}
+	}
+
+	// A backlink matches the note whatever the case and the '#'
+	if !r.pointsTo(parseNoteRef("#vpn setup"), project, "VPN Setup") || r.pointsTo(parseNoteRef("global:VPN Setup"), project, "VPN Setup") {
+		t.Error("pointsTo does not match the note by scope and name")
+	}
+}
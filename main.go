@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,11 +21,17 @@ func main() {
 		if len(os.Args) > 1 {
 			newArgs = append(newArgs, os.Args[1:]...)
 		}
+
+		// Shell completion calls "algo __complete <args>", which must become
+		// "al __complete go <args>"
+		if len(os.Args) > 1 && strings.HasPrefix(os.Args[1], "__complete") {
+			newArgs = append([]string{os.Args[0], os.Args[1], subCommand}, os.Args[2:]...)
+		}
 		os.Args = newArgs
 	}
 	
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		cmd.PrintError(err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alex/al/utils"
+)
+
+const (
+	ClientsFile    = "clients"
+	ClientsDirName = "client_data"
+)
+
+// Contact is a person to reach at a client
+type Contact struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Role  string `json:"role,omitempty"`
+}
+
+// Client groups the projects of a customer. Its notes and links are stored
+// in its own directory, shared by all its projects.
+type Client struct {
+	Description string    `json:"description,omitempty"`
+	Contacts    []Contact `json:"contacts,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LoadClients loads the clients map from the global directory
+func LoadClients() (map[string]Client, error) {
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(globalDir, ClientsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]Client), nil
+		}
+		return nil, fmt.Errorf("failed to read clients file: %w", err)
+	}
+
+	var clients map[string]Client
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("failed to parse clients file: %w", err)
+	}
+
+	return clients, nil
+}
+
+// SaveClients saves the clients map to the global directory
+func SaveClients(clients map[string]Client) error {
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(clients, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal clients: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(globalDir, ClientsFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write clients file: %w", err)
+	}
+
+	return nil
+}
+
+// FindClient finds a client by its name, ignoring case
+func FindClient(name string) (string, Client, error) {
+	clients, err := LoadClients()
+	if err != nil {
+		return "", Client{}, err
+	}
+
+	for clientName, client := range clients {
+		if strings.EqualFold(clientName, name) {
+			return clientName, client, nil
+		}
+	}
+
+	return "", Client{}, fmt.Errorf("client not found")
+}
+
+// getClientsDir returns the directory holding the data of all clients
+func getClientsDir() string {
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return filepath.Join(GlobalDirName, ClientsDirName)
+	}
+	return filepath.Join(globalDir, ClientsDirName)
+}
+
+// GetClientDir returns the directory of the notes and links of a client. It
+// is used as the project path of the client scope.
+func GetClientDir(name string) string {
+	return filepath.Join(getClientsDir(), utils.Slugify(name))
+}
+
+// IsClientDir reports whether a scope path is the directory of a client
+func IsClientDir(path string) bool {
+	return path != "" && filepath.Dir(filepath.Clean(path)) == getClientsDir()
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alex/al/utils"
+)
+
+// Origins of a configuration value
+const (
+	OriginDefault = "default"
+	OriginGlobal  = "global"
+	OriginProject = "project"
+)
+
+// ConfigKey describes a known configuration setting
+type ConfigKey struct {
+	Name        string
+	Type        string   // "int", "string" or "enum"
+	Values      []string // allowed values of an enum
+	Default     string
+	Description string
+	Command     bool // run by al, so only read from the global config
+	GlobalOnly  bool // only read from the global config, like commands
+}
+
+// IsGlobalOnly reports whether the setting is ignored in project config files
+func (k ConfigKey) IsGlobalOnly() bool {
+	return k.Command || k.GlobalOnly
+}
+
+// ConfigKeys is the schema of the settings accepted in config files
+var ConfigKeys = []ConfigKey{
+	{Name: "preview_length", Type: "int", Default: "60", Description: "Length of the note preview in listings"},
+	{Name: "editor", Type: "string", Default: "", Description: "Editor command (defaults to $EDITOR, then vim)", Command: true},
+	{Name: "clipboard", Type: "enum", Values: []string{"auto", "xclip", "xsel", "wl-copy", "pbcopy"}, Default: "auto", Description: "Clipboard backend"},
+	{Name: "date_format", Type: "string", Default: "2006-01-02", Description: "Date format in listings (Go layout)"},
+	{Name: "output", Type: "enum", Values: []string{"table", "json", "yaml", "tsv"}, Default: "table", Description: "Default output format", GlobalOnly: true},
+	{Name: "locale", Type: "enum", Values: []string{"fr", "en"}, Default: "fr", Description: "Language of listing labels"},
+	{Name: "sort", Type: "enum", Values: []string{"name", "created", "updated", "size"}, Default: "name", Description: "Sort order of listings"},
+	{Name: "browser", Type: "string", Default: "", Description: "Browser command (defaults to xdg-open)", Command: true},
+	{Name: "note_format", Type: "enum", Values: []string{"plain", "markdown"}, Default: "plain", Description: "Format of new notes"},
+}
+
+// ConfigValue is a setting resolved through the configuration layers
+type ConfigValue struct {
+	Key    string
+	Value  string
+	Origin string
+	Path   string // file defining the value, empty for defaults
+}
+
+// DefaultConfig returns the configuration used when nothing is set
+func DefaultConfig() Config {
+	return Config{
+		PreviewLength: 60,
+		Clipboard:     "auto",
+		DateFormat:    "2006-01-02",
+		Output:        "table",
+		Locale:        "fr",
+		Sort:          "name",
+		NoteFormat:    "plain",
+	}
+}
+
+// FindConfigKey returns the schema of the given setting
+func FindConfigKey(name string) (ConfigKey, error) {
+	for _, key := range ConfigKeys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+	return ConfigKey{}, fmt.Errorf("unknown config key '%s'", name)
+}
+
+// ParseConfigValue validates a value given as text and converts it to the
+// type stored in config files
+func ParseConfigValue(name, value string) (interface{}, error) {
+	key, err := FindConfigKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.Type {
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s must be a positive integer", name)
+		}
+		return n, nil
+	case "enum":
+		for _, v := range key.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of: %s", name, strings.Join(key.Values, ", "))
+	}
+
+	if key.Command {
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("%s cannot be empty (use al config unset %s)", name, name)
+		}
+		if _, err := utils.SplitCommand(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if name == "date_format" && time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(value) == value {
+		return nil, fmt.Errorf("%s must be a Go time layout such as 2006-01-02", name)
+	}
+	return value, nil
+}
+
+// GetGlobalConfigPath returns the path of the global config file
+func GetGlobalConfigPath() (string, error) {
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(globalDir, ConfigFile), nil
+}
+
+// GetLocalConfigPath returns the path of the config file of a project
+func GetLocalConfigPath(projectPath string) string {
+	return filepath.Join(GetLocalDir(projectPath), ConfigFile)
+}
+
+// LoadConfigLayer reads the raw settings of a config file
+func LoadConfigLayer(path string) (map[string]json.RawMessage, error) {
+	layer := make(map[string]json.RawMessage)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return layer, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if layer == nil {
+		layer = make(map[string]json.RawMessage)
+	}
+
+	return layer, nil
+}
+
+// SaveConfigLayer writes the raw settings of a config file
+func SaveConfigLayer(path string, layer map[string]json.RawMessage) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(layer, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateConfigLayer checks that every setting of a layer is known and valid
+func ValidateConfigLayer(layer map[string]json.RawMessage) error {
+	names := make([]string, 0, len(layer))
+	for name := range layer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key, err := FindConfigKey(name)
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(layer[name], &value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, isNumber := value.(float64); isNumber != (key.Type == "int") {
+			if key.Type == "int" {
+				return fmt.Errorf("%s must be a number", name)
+			}
+			return fmt.Errorf("%s must be a string", name)
+		}
+
+		if _, err := ParseConfigValue(name, RawConfigString(layer[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RawConfigString returns the text form of a raw config value
+func RawConfigString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// LoadConfigFor loads the configuration of a project: the defaults, then
+// the global config file, then the project .al_local/config file. An empty
+// projectPath only layers the global file. Command settings (editor,
+// browser) and the output format are only read from the global file.
+func LoadConfigFor(projectPath string) (Config, []ConfigValue, error) {
+	values := make(map[string]ConfigValue)
+	for _, key := range ConfigKeys {
+		values[key.Name] = ConfigValue{Key: key.Name, Value: key.Default, Origin: OriginDefault}
+	}
+
+	merged := make(map[string]json.RawMessage)
+	apply := func(path, origin string) error {
+		layer, err := LoadConfigLayer(path)
+		if err != nil {
+			return err
+		}
+		for name, raw := range layer {
+			key, err := FindConfigKey(name)
+			if err != nil {
+				continue
+			}
+			// A cloned project must not choose the commands al runs, nor
+			// switch every al command to a structured output
+			if key.IsGlobalOnly() && origin == OriginProject {
+				continue
+			}
+			merged[name] = raw
+			values[name] = ConfigValue{Key: name, Value: RawConfigString(raw), Origin: origin, Path: path}
+		}
+		return nil
+	}
+
+	globalPath, err := GetGlobalConfigPath()
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if err := apply(globalPath, OriginGlobal); err != nil {
+		return Config{}, nil, err
+	}
+	if projectPath != "" {
+		if err := apply(GetLocalConfigPath(projectPath), OriginProject); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	config := DefaultConfig()
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	list := make([]ConfigValue, 0, len(ConfigKeys))
+	for _, key := range ConfigKeys {
+		list = append(list, values[key.Name])
+	}
+
+	return config, list, nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,15 +14,52 @@ const (
 	LocalDirName   = ".al_local"
 	ProjectsFile   = "projects"
 	ConfigFile     = "config"
+	TrustFile      = "trust"
 )
 
+// Project statuses, an empty status is active
+const (
+	ProjectActive   = "active"
+	ProjectPaused   = "paused"
+	ProjectArchived = "archived"
+)
+
+// ProjectStatuses lists the statuses a project can have
+var ProjectStatuses = []string{ProjectActive, ProjectPaused, ProjectArchived}
+
 type Project struct {
-	Path      string   `json:"path"`
-	Shortcuts []string `json:"shortcuts"`
+	Path        string     `json:"path"`
+	Shortcuts   []string   `json:"shortcuts"`
+	Client      string     `json:"client,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// ProjectStatus returns the status of the project, active by default
+func (p Project) ProjectStatus() string {
+	if p.Status == "" {
+		return ProjectActive
+	}
+	return p.Status
+}
+
+// Archived reports whether the project is archived
+func (p Project) Archived() bool {
+	return p.Status == ProjectArchived
 }
 
 type Config struct {
-	PreviewLength int `json:"preview_length"`
+	PreviewLength int    `json:"preview_length"`
+	Editor        string `json:"editor,omitempty"`
+	Clipboard     string `json:"clipboard,omitempty"`
+	DateFormat    string `json:"date_format,omitempty"`
+	Output        string `json:"output,omitempty"`
+	Locale        string `json:"locale,omitempty"`
+	Sort          string `json:"sort,omitempty"`
+	Browser       string `json:"browser,omitempty"`
+	NoteFormat    string `json:"note_format,omitempty"`
 }
 
 // GetGlobalDir returns the path to the global .al_global directory
@@ -120,12 +158,12 @@ func LoadConfig() (Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Config{PreviewLength: 60}, nil
+			return DefaultConfig(), nil
 		}
 		return Config{}, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
+	config := DefaultConfig()
 	if err := json.Unmarshal(data, &config); err != nil {
 		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
 	}
@@ -173,11 +211,128 @@ func FindProjectByShortcut(shortcut string) (string, Project, error) {
 	return "", Project{}, fmt.Errorf("project not found")
 }
 
+// FindProjectByPath finds the project containing the given directory.
+// The deepest project wins when projects are nested.
+func FindProjectByPath(dir string) (string, Project, error) {
+	projects, err := LoadProjects()
+	if err != nil {
+		return "", Project{}, err
+	}
+
+	dir = filepath.Clean(dir)
+
+	var foundName string
+	var found Project
+	for name, project := range projects {
+		path := filepath.Clean(project.Path)
+		if dir != path && !strings.HasPrefix(dir, path+string(filepath.Separator)) {
+			continue
+		}
+		if len(path) > len(found.Path) {
+			foundName, found = name, project
+		}
+	}
+
+	if foundName == "" {
+		return "", Project{}, fmt.Errorf("project not found")
+	}
+	return foundName, found, nil
+}
+
+// TrustEntry is the decision about loading the environment of a project.
+// Hash is the hash of the env file the decision was made for.
+type TrustEntry struct {
+	Allowed bool   `json:"allowed"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// LoadTrust loads the trust decisions (project path -> entry) used by the
+// shell integration before loading a project environment
+func LoadTrust() (map[string]TrustEntry, error) {
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	trust := make(map[string]TrustEntry)
+	data, err := os.ReadFile(filepath.Join(globalDir, TrustFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return trust, nil
+		}
+		return nil, fmt.Errorf("failed to read trust file: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse trust file: %w", err)
+	}
+	for path, value := range raw {
+		var entry TrustEntry
+		// Older versions only stored a boolean, without hash
+		if err := json.Unmarshal(value, &entry.Allowed); err != nil {
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return nil, fmt.Errorf("failed to parse trust file: %w", err)
+			}
+		}
+		trust[path] = entry
+	}
+
+	return trust, nil
+}
+
+// SetTrust records whether the environment of the project at path may be
+// loaded, for the env file content with the given hash
+func SetTrust(path string, allowed bool, hash string) error {
+	if err := EnsureGlobalDir(); err != nil {
+		return err
+	}
+
+	trust, err := LoadTrust()
+	if err != nil {
+		return err
+	}
+	trust[path] = TrustEntry{Allowed: allowed, Hash: hash}
+
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(trust, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(globalDir, TrustFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write trust file: %w", err)
+	}
+
+	return nil
+}
+
 // GetLocalDir returns the path to the local .al_local directory in the given path
 func GetLocalDir(projectPath string) string {
 	return filepath.Join(projectPath, LocalDirName)
 }
 
+// GetDataDir returns the directory holding the notes and links of a project,
+// the directory of a client for client scopes, or the global directory when
+// projectPath is empty (global scope)
+func GetDataDir(projectPath string) string {
+	if IsClientDir(projectPath) {
+		return projectPath
+	}
+	if projectPath != "" {
+		return GetLocalDir(projectPath)
+	}
+	globalDir, err := GetGlobalDir()
+	if err != nil {
+		return GlobalDirName
+	}
+	return globalDir
+}
+
 // EnsureLocalDir creates the local directory if it doesn't exist
 func EnsureLocalDir(projectPath string) error {
 	localDir := GetLocalDir(projectPath)
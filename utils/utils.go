@@ -11,8 +11,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"syscall"
+	"unicode"
 
 	"github.com/atotto/clipboard"
 	"golang.org/x/crypto/pbkdf2"
@@ -87,11 +89,12 @@ func FindSimilarStrings(target string, candidates []string, maxDistance int) []s
 	return similar
 }
 
-// ReadPassword reads a password from stdin without echoing
+// ReadPassword reads a password from stdin without echoing.
+// The prompt goes to stderr so it never ends up in captured output.
 func ReadPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
+	fmt.Fprint(os.Stderr, prompt)
 	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
 	if err != nil {
 		return "", err
 	}
@@ -185,12 +188,72 @@ func Decrypt(ciphertext, password string) (string, error) {
 
 // OpenEditor opens the default editor (vim) with the given file
 func OpenEditor(filepath string) error {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
+	return OpenEditorWith("", filepath)
+}
+
+// SplitCommand splits a command line into its program and arguments. Single
+// and double quotes group words and a backslash escapes the next character,
+// as in a shell, but nothing is expanded.
+func SplitCommand(command string) ([]string, error) {
+	var parts []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case unicode.IsSpace(r):
+			if inWord {
+				parts = append(parts, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command '%s'", command)
+	}
+	if inWord {
+		parts = append(parts, word.String())
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return parts, nil
+}
+
+// OpenEditorWith opens the given editor command (which may carry arguments,
+// e.g. "code --wait") with the file, falling back to $EDITOR then vim
+func OpenEditorWith(editor, filepath string) error {
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
 		editor = "vim"
 	}
 
-	cmd := exec.Command(editor, filepath)
+	parts, err := SplitCommand(editor)
+	if err != nil {
+		return fmt.Errorf("invalid editor: %w", err)
+	}
+	cmd := exec.Command(parts[0], append(parts[1:], filepath)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -198,15 +261,80 @@ func OpenEditor(filepath string) error {
 	return cmd.Run()
 }
 
+// HasDisplay reports whether a graphical session is available to open a browser
+func HasDisplay() bool {
+	if runtime.GOOS != "linux" {
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// OpenURLs opens the URLs with the given browser command (which may carry
+// arguments), or with the system opener (xdg-open, open) when it is empty
+func OpenURLs(browser string, urls []string) error {
+	if strings.TrimSpace(browser) != "" {
+		parts, err := SplitCommand(browser)
+		if err != nil {
+			return fmt.Errorf("invalid browser: %w", err)
+		}
+		return exec.Command(parts[0], append(parts[1:], urls...)...).Start()
+	}
+
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+
+	// xdg-open only accepts one URL per call
+	for _, url := range urls {
+		if err := exec.Command(opener, url).Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CopyToClipboard copies text to the system clipboard
 func CopyToClipboard(text string) error {
 	return clipboard.WriteAll(text)
 }
 
+// CopyToClipboardWith copies text using the given clipboard backend
+// ("auto" or empty lets the clipboard library pick one)
+func CopyToClipboardWith(backend, text string) error {
+	var args []string
+	switch backend {
+	case "", "auto":
+		return CopyToClipboard(text)
+	case "xclip":
+		args = []string{"xclip", "-in", "-selection", "clipboard"}
+	case "xsel":
+		args = []string{"xsel", "--input", "--clipboard"}
+	case "wl-copy":
+		args = []string{"wl-copy"}
+	case "pbcopy":
+		args = []string{"pbcopy"}
+	default:
+		return fmt.Errorf("unknown clipboard backend '%s'", backend)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", backend, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // AskConfirmation asks the user for yes/no confirmation
 func AskConfirmation(prompt string) bool {
+	return AskConfirmationOn(os.Stdout, prompt)
+}
+
+// AskConfirmationOn asks for yes/no confirmation, writing the prompt to out
+func AskConfirmationOn(out io.Writer, prompt string) bool {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Printf("%s (y/n): ", prompt)
+	fmt.Fprintf(out, "%s (y/n): ", prompt)
 	
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -217,6 +345,66 @@ func AskConfirmation(prompt string) bool {
 	return response == "y" || response == "yes"
 }
 
+// IsTerminal reports whether the given file is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Page writes text to stdout, through $PAGER (or less -R) when stdout is a
+// terminal and the text is taller than it
+func Page(text string) error {
+	if !IsTerminal(os.Stdout) {
+		_, err := fmt.Print(text)
+		return err
+	}
+
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || strings.Count(text, "\n") < height {
+		_, err := fmt.Print(text)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -R"
+	}
+
+	// A blank or invalid $PAGER prints the text as is
+	parts, err := SplitCommand(pager)
+	if err != nil {
+		_, err := fmt.Print(text)
+		return err
+	}
+	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// Print the text when the pager cannot be started
+		if _, ok := err.(*exec.ExitError); !ok {
+			_, err := fmt.Print(text)
+			return err
+		}
+	}
+	return nil
+}
+
+// Slugify turns s into a lowercase name made of letters, digits and dashes
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
 // TruncateString truncates a string to the given length
 func TruncateString(s string, length int) string {
 	if len(s) <= length {